@@ -0,0 +1,128 @@
+package alert
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yarlson/duh/service"
+)
+
+// Config is the alerting configuration, usually loaded from a JSON file
+type Config struct {
+	// Webhooks receive notifications of rules that have no webhooks of their own
+	Webhooks []string `json:"webhooks"`
+	Rules    []Rule   `json:"rules"`
+}
+
+// Duration is a time.Duration that reads from JSON strings such as "5m"
+type Duration time.Duration
+
+// UnmarshalJSON accepts a Go duration string or a number of seconds
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err == nil {
+		*d = Duration(seconds * float64(time.Second))
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string or a number of seconds")
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalJSON writes the duration as a Go duration string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// LoadConfig reads and validates an alerting configuration file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read alert config: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse alert config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate checks every rule and compiles its selector
+func (c *Config) Validate() error {
+	names := make(map[string]bool)
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		if rule.Name == "" {
+			return fmt.Errorf("rule %d: name required", i)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("rule %s: duplicate name", rule.Name)
+		}
+		names[rule.Name] = true
+
+		if err := rule.compile(); err != nil {
+			return fmt.Errorf("rule %s: %w", rule.Name, err)
+		}
+		if len(rule.Webhooks) == 0 && len(c.Webhooks) == 0 {
+			return fmt.Errorf("rule %s: no webhooks configured", rule.Name)
+		}
+		for _, url := range append(rule.Webhooks, c.Webhooks...) {
+			if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+				return fmt.Errorf("rule %s: invalid webhook URL %q", rule.Name, url)
+			}
+		}
+	}
+	return nil
+}
+
+// webhooks returns the URLs notified for a rule
+func (c *Config) webhooks(rule *Rule) []string {
+	if len(rule.Webhooks) > 0 {
+		return rule.Webhooks
+	}
+	return c.Webhooks
+}
+
+// compile parses the rule's selector and checks its metric and comparator
+func (r *Rule) compile() error {
+	if _, ok := metrics[r.Metric]; !ok {
+		return fmt.Errorf("unknown metric %q", r.Metric)
+	}
+	if r.Op == "" {
+		r.Op = ">"
+	}
+	if _, ok := comparators[r.Op]; !ok {
+		return fmt.Errorf("unknown comparator %q", r.Op)
+	}
+	if r.For < 0 {
+		return fmt.Errorf("for must not be negative")
+	}
+
+	filters := make([]service.Filter, 0, len(r.Labels)+1)
+	for key, value := range r.Labels {
+		filters = append(filters, service.MatchLabel(key+"="+value))
+	}
+	if r.Match != "" {
+		filter, err := service.ParseQuery(r.Match)
+		if err != nil {
+			return err
+		}
+		filters = append(filters, filter)
+	}
+	r.selector = service.MatchAll(filters...)
+	return nil
+}
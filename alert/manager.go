@@ -0,0 +1,182 @@
+package alert
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yarlson/duh/store"
+)
+
+// Alert states. A pending alert fires once its condition has held for the rule's
+// For duration and resolves when the condition clears.
+const (
+	StatePending  = "pending"
+	StateFiring   = "firing"
+	StateResolved = "resolved"
+)
+
+// Alert is the state of one rule for one container
+type Alert struct {
+	Rule          string     `json:"rule"`
+	State         string     `json:"state"`
+	ContainerID   string     `json:"container_id"`
+	ContainerName string     `json:"container_name"`
+	Image         string     `json:"image"`
+	Metric        string     `json:"metric"`
+	Value         float64    `json:"value"`
+	Threshold     float64    `json:"threshold"`
+	StartedAt     time.Time  `json:"started_at"` // When the condition first held
+	FiredAt       *time.Time `json:"fired_at,omitempty"`
+	ResolvedAt    *time.Time `json:"resolved_at,omitempty"`
+}
+
+// Manager evaluates alert rules against container data and notifies webhooks when
+// alerts fire and resolve. Each alert is delivered once per transition.
+type Manager struct {
+	cfg      *Config
+	notifier *notifier
+	now      func() time.Time
+
+	mu     sync.Mutex
+	alerts map[string]*Alert
+}
+
+// Option configures a Manager
+type Option func(*Manager)
+
+// WithClock replaces time.Now, for tests
+func WithClock(now func() time.Time) Option {
+	return func(m *Manager) {
+		m.now = now
+	}
+}
+
+// WithRetry sets how many times a webhook delivery is attempted and the delay
+// before the first retry, which doubles for every further attempt
+func WithRetry(attempts int, backoff time.Duration) Option {
+	return func(m *Manager) {
+		m.notifier.attempts = attempts
+		m.notifier.backoff = backoff
+	}
+}
+
+// NewManager creates a manager for a validated configuration
+func NewManager(cfg *Config, opts ...Option) *Manager {
+	m := &Manager{
+		cfg:      cfg,
+		notifier: newNotifier(),
+		now:      time.Now,
+		alerts:   make(map[string]*Alert),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Evaluate updates every alert from the current containers. It is meant to run after
+// each sync; containers that disappeared resolve their alerts. Notifications are
+// delivered in the background and do not depend on ctx.
+func (m *Manager) Evaluate(_ context.Context, containers []store.ContainerData) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	active := make(map[string]bool)
+
+	for i := range m.cfg.Rules {
+		rule := &m.cfg.Rules[i]
+		for _, c := range containers {
+			value, holds := rule.evaluate(c)
+			if !holds {
+				continue
+			}
+
+			key := alertKey(rule.Name, c.ID)
+			active[key] = true
+
+			alert, exists := m.alerts[key]
+			if !exists {
+				alert = &Alert{
+					Rule:          rule.Name,
+					State:         StatePending,
+					ContainerID:   c.ID,
+					ContainerName: containerName(c),
+					Image:         c.Image,
+					Metric:        rule.Metric,
+					Threshold:     rule.Threshold,
+					StartedAt:     now,
+				}
+				m.alerts[key] = alert
+			}
+			alert.Value = value
+
+			if alert.State == StatePending && now.Sub(alert.StartedAt) >= time.Duration(rule.For) {
+				alert.State = StateFiring
+				alert.FiredAt = &now
+				m.notifier.send(m.cfg.webhooks(rule), *alert)
+			}
+		}
+	}
+
+	for key, alert := range m.alerts {
+		if active[key] {
+			continue
+		}
+		delete(m.alerts, key)
+		if alert.State != StateFiring {
+			continue // pending alerts clear silently
+		}
+		alert.State = StateResolved
+		alert.ResolvedAt = &now
+		if rule := m.rule(alert.Rule); rule != nil {
+			m.notifier.send(m.cfg.webhooks(rule), *alert)
+		}
+	}
+}
+
+// Alerts returns the pending and firing alerts ordered by rule and container
+func (m *Manager) Alerts() []Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	alerts := make([]Alert, 0, len(m.alerts))
+	for _, alert := range m.alerts {
+		alerts = append(alerts, *alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].Rule != alerts[j].Rule {
+			return alerts[i].Rule < alerts[j].Rule
+		}
+		return alerts[i].ContainerID < alerts[j].ContainerID
+	})
+	return alerts
+}
+
+// Close stops sending notifications and waits for queued ones to be delivered
+func (m *Manager) Close() {
+	m.notifier.close()
+}
+
+func (m *Manager) rule(name string) *Rule {
+	for i := range m.cfg.Rules {
+		if m.cfg.Rules[i].Name == name {
+			return &m.cfg.Rules[i]
+		}
+	}
+	return nil
+}
+
+func alertKey(rule, containerID string) string {
+	return rule + "/" + containerID
+}
+
+func containerName(c store.ContainerData) string {
+	if len(c.Names) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(c.Names[0], "/")
+}
@@ -0,0 +1,227 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yarlson/duh/store"
+)
+
+type webhookRecorder struct {
+	mu       sync.Mutex
+	alerts   []Alert
+	failures int // requests to reject with 503 before accepting
+	requests int
+}
+
+func (rec *webhookRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	rec.requests++
+	if rec.failures > 0 {
+		rec.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	var alert Alert
+	if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	rec.alerts = append(rec.alerts, alert)
+}
+
+func (rec *webhookRecorder) received() []Alert {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]Alert(nil), rec.alerts...)
+}
+
+func memoryContainer(id string, usage uint64, labels map[string]string) store.ContainerData {
+	stats := &store.Stats{}
+	stats.Memory.Usage = usage
+	stats.Memory.Limit = 100
+	return store.ContainerData{ID: id, Names: []string{"/" + id}, State: "running", Labels: labels, Stats: stats}
+}
+
+func TestManagerLifecycle(t *testing.T) {
+	rec := &webhookRecorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	cfg := &Config{
+		Webhooks: []string{srv.URL},
+		Rules: []Rule{{
+			Name:      "high-memory",
+			Metric:    MetricMemoryPercent,
+			Threshold: 90,
+			For:       Duration(5 * time.Minute),
+			Labels:    map[string]string{"team": "payments"},
+		}},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	now := time.Now()
+	manager := NewManager(cfg, WithClock(func() time.Time { return now }))
+	ctx := context.Background()
+	payments := map[string]string{"team": "payments"}
+
+	// Above the threshold, but not for long enough
+	manager.Evaluate(ctx, []store.ContainerData{
+		memoryContainer("api", 95, payments),
+		memoryContainer("other", 99, nil), // not selected
+	})
+	if alerts := manager.Alerts(); len(alerts) != 1 || alerts[0].State != StatePending {
+		t.Fatalf("Expected one pending alert, got %+v", alerts)
+	}
+
+	now = now.Add(5 * time.Minute)
+	manager.Evaluate(ctx, []store.ContainerData{memoryContainer("api", 96, payments)})
+	// Still firing on the next evaluation, but not notified again
+	now = now.Add(time.Minute)
+	manager.Evaluate(ctx, []store.ContainerData{memoryContainer("api", 97, payments)})
+	if alerts := manager.Alerts(); len(alerts) != 1 || alerts[0].State != StateFiring || alerts[0].Value != 97 {
+		t.Fatalf("Expected one firing alert, got %+v", alerts)
+	}
+
+	now = now.Add(time.Minute)
+	manager.Evaluate(ctx, []store.ContainerData{memoryContainer("api", 50, payments)})
+	if alerts := manager.Alerts(); len(alerts) != 0 {
+		t.Fatalf("Expected no alerts after recovery, got %+v", alerts)
+	}
+	manager.Close()
+
+	received := rec.received()
+	if len(received) != 2 {
+		t.Fatalf("Expected 2 notifications, got %d: %+v", len(received), received)
+	}
+	if received[0].State != StateFiring || received[0].ContainerID != "api" || received[0].FiredAt == nil {
+		t.Errorf("Unexpected firing notification %+v", received[0])
+	}
+	if received[1].State != StateResolved || received[1].ResolvedAt == nil {
+		t.Errorf("Unexpected resolved notification %+v", received[1])
+	}
+}
+
+func TestManagerUnexpectedExit(t *testing.T) {
+	rec := &webhookRecorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	cfg := &Config{
+		Webhooks: []string{srv.URL},
+		Rules:    []Rule{{Name: "crashed", Metric: MetricUnexpectedExit}},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	manager := NewManager(cfg)
+
+	code := 137
+	crashed := store.ContainerData{ID: "worker", State: "exited", ExitCode: &code}
+	stopped := store.ContainerData{ID: "web", State: "exited", ExitCode: &code,
+		LastAction: &store.ActionResult{Action: "stop", Outcome: store.OutcomeSucceeded}}
+	// Stopped with docker stop, so the exit was requested even though duh did not do it
+	external := store.ContainerData{ID: "db", State: "exited", ExitCode: &code,
+		Diagnostics: &store.Diagnostics{Exits: []store.ExitRecord{{ExitCode: code, Stopped: true}}}}
+	manager.Evaluate(context.Background(), []store.ContainerData{crashed, stopped, external})
+	manager.Close()
+
+	received := rec.received()
+	if len(received) != 1 || received[0].ContainerID != "worker" || received[0].State != StateFiring {
+		t.Errorf("Expected one firing alert for worker, got %+v", received)
+	}
+}
+
+func TestManagerDeliversAfterCancel(t *testing.T) {
+	rec := &webhookRecorder{failures: 1}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	cfg := &Config{
+		Webhooks: []string{srv.URL},
+		Rules:    []Rule{{Name: "crashed", Metric: MetricUnexpectedExit}},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	manager := NewManager(cfg)
+	manager.notifier.backoff = time.Millisecond
+
+	// The Sync that raised the alert is cancelled before the queue is drained
+	ctx, cancel := context.WithCancel(context.Background())
+	code := 1
+	manager.Evaluate(ctx, []store.ContainerData{{ID: "worker", State: "exited", ExitCode: &code}})
+	cancel()
+	manager.Close()
+
+	if received := rec.received(); len(received) != 1 || received[0].ContainerID != "worker" {
+		t.Errorf("Expected the alert to be delivered after cancellation, got %+v", received)
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	rec := &webhookRecorder{failures: 2}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	n := newNotifier()
+	n.backoff = time.Millisecond
+	n.send([]string{srv.URL}, Alert{Rule: "test", State: StateFiring})
+	n.close()
+
+	if rec.requests != 3 || len(rec.received()) != 1 {
+		t.Errorf("Expected delivery on the third attempt, got %d requests and %d deliveries", rec.requests, len(rec.received()))
+	}
+
+	// Client errors are not retried
+	badRequest := 0
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		badRequest++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer bad.Close()
+	if err := n.deliver(context.Background(), bad.URL, []byte("{}")); err == nil || badRequest != 1 {
+		t.Errorf("Expected a single failed attempt, got %d attempts (err %v)", badRequest, err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	var cfg Config
+	data := `{
+		"webhooks": ["https://hooks.example.com/duh"],
+		"rules": [{"name": "busy", "metric": "cpu_percent", "op": ">=", "threshold": 80, "for": "2m", "match": "state:running -image:redis"}]
+	}`
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if time.Duration(cfg.Rules[0].For) != 2*time.Minute {
+		t.Errorf("Expected for of 2m, got %v", time.Duration(cfg.Rules[0].For))
+	}
+
+	invalid := []Config{
+		{Webhooks: []string{"https://x"}, Rules: []Rule{{Metric: MetricCPUPercent}}},
+		{Webhooks: []string{"https://x"}, Rules: []Rule{{Name: "a", Metric: "disk"}}},
+		{Webhooks: []string{"https://x"}, Rules: []Rule{{Name: "a", Metric: MetricCPUPercent, Op: "~"}}},
+		{Webhooks: []string{"https://x"}, Rules: []Rule{{Name: "a", Metric: MetricCPUPercent, Match: "colour:red"}}},
+		{Rules: []Rule{{Name: "a", Metric: MetricCPUPercent}}},
+		{Webhooks: []string{"ftp://x"}, Rules: []Rule{{Name: "a", Metric: MetricCPUPercent}}},
+		{Webhooks: []string{"https://x"}, Rules: []Rule{{Name: "a", Metric: MetricCPUPercent}, {Name: "a", Metric: MetricCPUPercent}}},
+	}
+	for i, cfg := range invalid {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Config %d: expected validation error", i)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package alert
+
+import (
+	"github.com/yarlson/duh/service"
+	"github.com/yarlson/duh/store"
+)
+
+// Metrics a rule can watch
+const (
+	MetricCPUPercent     = "cpu_percent"
+	MetricMemoryPercent  = "memory_percent" // Memory usage in percent of the limit
+	MetricMemoryUsage    = "memory_usage"   // Bytes
+	MetricNetworkRxRate  = "network_rx_rate"
+	MetricNetworkTxRate  = "network_tx_rate"
+	MetricUnexpectedExit = "unexpected_exit" // 1 when a container exited non-zero without a stop or kill request
+	MetricError          = "error"           // 1 while a container is in the error state
+)
+
+// Rule raises an alert for every selected container whose metric compares true
+// against the threshold for at least the For duration
+type Rule struct {
+	Name      string            `json:"name"`
+	Metric    string            `json:"metric"`
+	Op        string            `json:"op"` // >, >=, <, <=, == or !=; defaults to >
+	Threshold float64           `json:"threshold"`
+	For       Duration          `json:"for"`
+	Labels    map[string]string `json:"labels,omitempty"` // Container labels that must all match
+	Match     string            `json:"match,omitempty"`  // Filter expression, see service.ParseQuery
+	Webhooks  []string          `json:"webhooks,omitempty"`
+
+	selector service.Filter
+}
+
+// metrics read a rule metric from a container. The second result is false when the
+// container has no value, for example a stopped container without stats.
+var metrics = map[string]func(store.ContainerData) (float64, bool){
+	MetricCPUPercent: func(c store.ContainerData) (float64, bool) {
+		if c.Stats == nil {
+			return 0, false
+		}
+		return c.Stats.CPU.Usage, true
+	},
+	MetricMemoryPercent: func(c store.ContainerData) (float64, bool) {
+		if c.Stats == nil || c.Stats.Memory.Limit == 0 {
+			return 0, false
+		}
+		return float64(c.Stats.Memory.Usage) / float64(c.Stats.Memory.Limit) * 100, true
+	},
+	MetricMemoryUsage: func(c store.ContainerData) (float64, bool) {
+		if c.Stats == nil {
+			return 0, false
+		}
+		return float64(c.Stats.Memory.Usage), true
+	},
+	MetricNetworkRxRate: func(c store.ContainerData) (float64, bool) {
+		if c.Stats == nil {
+			return 0, false
+		}
+		return c.Stats.Network.RxRate, true
+	},
+	MetricNetworkTxRate: func(c store.ContainerData) (float64, bool) {
+		if c.Stats == nil {
+			return 0, false
+		}
+		return c.Stats.Network.TxRate, true
+	},
+	MetricUnexpectedExit: func(c store.ContainerData) (float64, bool) {
+		if c.State != "exited" || c.ExitCode == nil || *c.ExitCode == 0 {
+			return 0, true
+		}
+		if c.LastAction != nil && c.LastAction.Action == "stop" {
+			return 0, true
+		}
+		// Stops requested outside duh, such as docker stop, are recorded on the exit
+		if d := c.Diagnostics; d != nil && len(d.Exits) > 0 && d.Exits[len(d.Exits)-1].Stopped {
+			return 0, true
+		}
+		return 1, true
+	},
+	MetricError: func(c store.ContainerData) (float64, bool) {
+		if c.State == store.StateError {
+			return 1, true
+		}
+		return 0, true
+	},
+}
+
+var comparators = map[string]func(a, b float64) bool{
+	">":  func(a, b float64) bool { return a > b },
+	">=": func(a, b float64) bool { return a >= b },
+	"<":  func(a, b float64) bool { return a < b },
+	"<=": func(a, b float64) bool { return a <= b },
+	"==": func(a, b float64) bool { return a == b },
+	"!=": func(a, b float64) bool { return a != b },
+}
+
+// evaluate returns the rule's metric for a container and whether the condition holds
+func (r *Rule) evaluate(c store.ContainerData) (float64, bool) {
+	if r.selector != nil && !r.selector(c) {
+		return 0, false
+	}
+	value, ok := metrics[r.Metric](c)
+	if !ok {
+		return 0, false
+	}
+	return value, comparators[r.Op](value, r.Threshold)
+}
@@ -0,0 +1,150 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/yarlson/duh/logger"
+)
+
+const (
+	defaultAttempts = 3
+	defaultBackoff  = time.Second
+	webhookTimeout  = 10 * time.Second
+	deliveryTimeout = time.Minute
+	webhookQueue    = 100
+)
+
+// notifier delivers alerts to webhooks in the background, retrying failed deliveries.
+// Each URL has its own queue so that notifications arrive in the order they were sent.
+type notifier struct {
+	client   *http.Client
+	attempts int
+	backoff  time.Duration
+
+	mu     sync.Mutex
+	queues map[string]chan delivery
+	closed bool
+	wg     sync.WaitGroup
+}
+
+type delivery struct {
+	alert   Alert
+	payload []byte
+}
+
+func newNotifier() *notifier {
+	return &notifier{
+		client:   &http.Client{Timeout: webhookTimeout},
+		attempts: defaultAttempts,
+		backoff:  defaultBackoff,
+		queues:   make(map[string]chan delivery),
+	}
+}
+
+// send queues the alert for every URL without blocking the caller. Alerts are
+// dropped when a webhook's queue is full.
+func (n *notifier) send(urls []string, alert Alert) {
+	payload, err := json.Marshal(alert)
+	if err != nil {
+		logger.New().Warn("Error encoding alert %s: %v", alert.Rule, err)
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		return
+	}
+
+	for _, url := range urls {
+		queue, exists := n.queues[url]
+		if !exists {
+			queue = make(chan delivery, webhookQueue)
+			n.queues[url] = queue
+			n.wg.Add(1)
+			go n.run(url, queue)
+		}
+
+		select {
+		case queue <- delivery{alert: alert, payload: payload}:
+		default:
+			logger.New().Warn("Alert %s for %s dropped, queue for %s is full", alert.Rule, alert.ContainerName, url)
+		}
+	}
+}
+
+func (n *notifier) run(url string, queue <-chan delivery) {
+	defer n.wg.Done()
+	for d := range queue {
+		// Deliveries outlive the Sync that queued them, so they get their own deadline
+		ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+		if err := n.deliver(ctx, url, d.payload); err != nil {
+			logger.New().Warn("Alert %s for %s not delivered to %s: %v", d.alert.Rule, d.alert.ContainerName, url, err)
+		}
+		cancel()
+	}
+}
+
+// deliver posts the payload until it is accepted, the attempts run out or ctx ends.
+// Client errors other than 429 are not retried.
+func (n *notifier) deliver(ctx context.Context, url string, payload []byte) error {
+	backoff := n.backoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		retry, err = n.post(ctx, url, payload)
+		if err == nil || !retry || attempt >= n.attempts {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+func (n *notifier) post(ctx context.Context, url string, payload []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return false, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("do request: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+}
+
+// close stops accepting alerts and waits until the queued ones are delivered
+func (n *notifier) close() {
+	n.mu.Lock()
+	if !n.closed {
+		n.closed = true
+		for _, queue := range n.queues {
+			close(queue)
+		}
+	}
+	n.mu.Unlock()
+
+	n.wg.Wait()
+}
@@ -3,20 +3,37 @@ package docker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 )
 
+// ErrNotFound is returned when a container does not exist
+var ErrNotFound = errors.New("container not found")
+
 // Container represents a Docker container
 type Container struct {
-	ID      string   `json:"Id"`
-	Names   []string `json:"Names"`
-	Image   string   `json:"Image"`
-	State   string   `json:"State"`
-	Status  string   `json:"Status"`
-	Created int64    `json:"Created"`
+	ID      string            `json:"Id"`
+	Names   []string          `json:"Names"`
+	Image   string            `json:"Image"`
+	State   string            `json:"State"`
+	Status  string            `json:"Status"`
+	Created int64             `json:"Created"`
+	Labels  map[string]string `json:"Labels"`
+	Ports   []Port            `json:"Ports"`
+}
+
+// Port is a container port and, when published, the host address it is bound to
+type Port struct {
+	IP          string `json:"IP,omitempty"`
+	PrivatePort uint16 `json:"PrivatePort"`
+	PublicPort  uint16 `json:"PublicPort,omitempty"`
+	Type        string `json:"Type"` // tcp, udp or sctp
 }
 
 // ContainerStats represents container resource usage statistics
@@ -38,6 +55,26 @@ type ContainerStats struct {
 		Usage uint64 `json:"usage"`
 		Limit uint64 `json:"limit"`
 	} `json:"memory_stats"`
+	BlkioStats struct {
+		IOServiceBytesRecursive []BlkioEntry `json:"io_service_bytes_recursive"`
+	} `json:"blkio_stats"`
+	Networks map[string]NetworkStats `json:"networks"`
+	Read     time.Time               `json:"read"`
+}
+
+// BlkioEntry is one block device counter. Op is Read, Write, Sync, Async, Discard
+// or Total; cgroup v2 hosts report it in lower case.
+type BlkioEntry struct {
+	Major uint64 `json:"major"`
+	Minor uint64 `json:"minor"`
+	Op    string `json:"op"`
+	Value uint64 `json:"value"`
+}
+
+// NetworkStats holds the traffic counters of one container network interface
+type NetworkStats struct {
+	RxBytes uint64 `json:"rx_bytes"`
+	TxBytes uint64 `json:"tx_bytes"`
 }
 
 // Client represents a Docker API client
@@ -90,6 +127,44 @@ func (c *Client) ListContainers(ctx context.Context, all bool) ([]Container, err
 	return containers, nil
 }
 
+// GetContainer returns a single container in the same form as ListContainers.
+// It returns ErrNotFound if the container does not exist.
+func (c *Client) GetContainer(ctx context.Context, containerID string) (*Container, error) {
+	filters, err := json.Marshal(map[string][]string{"id": {containerID}})
+	if err != nil {
+		return nil, fmt.Errorf("encode filters: %w", err)
+	}
+	url := "http://docker/containers/json?all=true&filters=" + url.QueryEscape(string(filters))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var containers []Container
+	if err := json.NewDecoder(resp.Body).Decode(&containers); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	// The id filter matches prefixes, so pick the exact match
+	for _, container := range containers {
+		if container.ID == containerID {
+			return &container, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
 // GetContainerStats returns stats for a specific container
 func (c *Client) GetContainerStats(ctx context.Context, containerID string) (*ContainerStats, error) {
 	url := fmt.Sprintf("http://docker/containers/%s/stats?stream=false", containerID)
@@ -144,9 +219,29 @@ func (c *Client) StartContainer(ctx context.Context, containerID string) error {
 	return nil
 }
 
+// StopOptions controls how StopContainer stops a container
+type StopOptions struct {
+	// Timeout is the number of seconds to wait before killing the container.
+	// Nil uses the container's StopTimeout or Docker's default of 10 seconds.
+	Timeout *int
+	// Signal is sent to stop the container, e.g. SIGINT. Empty uses the
+	// container's StopSignal.
+	Signal string
+}
+
 // StopContainer stops a Docker container
-func (c *Client) StopContainer(ctx context.Context, containerID string) error {
+func (c *Client) StopContainer(ctx context.Context, containerID string, opts StopOptions) error {
+	query := url.Values{}
+	if opts.Timeout != nil {
+		query.Set("t", strconv.Itoa(*opts.Timeout))
+	}
+	if opts.Signal != "" {
+		query.Set("signal", opts.Signal)
+	}
 	url := fmt.Sprintf("http://docker/containers/%s/stop", containerID)
+	if len(query) > 0 {
+		url += "?" + query.Encode()
+	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
 	if err != nil {
@@ -2,6 +2,7 @@ package docker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"strings"
@@ -186,7 +187,8 @@ func TestStartStopContainer(t *testing.T) {
 	container := containers[0]
 
 	// Stop the container
-	err = client.StopContainer(ctx, container.ID)
+	timeout := 5
+	err = client.StopContainer(ctx, container.ID, StopOptions{Timeout: &timeout, Signal: "SIGTERM"})
 	if err != nil {
 		t.Fatalf("StopContainer failed: %v", err)
 	}
@@ -243,3 +245,56 @@ func TestStartStopContainer(t *testing.T) {
 		t.Errorf("Expected container state to be 'running', got '%s'", startedContainer.State)
 	}
 }
+
+func TestGetContainer(t *testing.T) {
+	cleanup := setupTestContainers(t)
+	defer cleanup()
+
+	client := NewClient()
+	ctx := context.Background()
+
+	containers, err := client.ListContainers(ctx, true)
+	if err != nil {
+		t.Fatalf("ListContainers failed: %v", err)
+	}
+
+	container, err := client.GetContainer(ctx, containers[0].ID)
+	if err != nil {
+		t.Fatalf("GetContainer failed: %v", err)
+	}
+	if container.ID != containers[0].ID {
+		t.Errorf("Expected container %s, got %s", containers[0].ID, container.ID)
+	}
+
+	if _, err := client.GetContainer(ctx, "does-not-exist"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestInspectContainer(t *testing.T) {
+	cleanup := setupTestContainers(t)
+	defer cleanup()
+
+	client := NewClient()
+	ctx := context.Background()
+
+	containers, err := client.ListContainers(ctx, true)
+	if err != nil {
+		t.Fatalf("ListContainers failed: %v", err)
+	}
+
+	inspect, err := client.InspectContainer(ctx, containers[0].ID)
+	if err != nil {
+		t.Fatalf("InspectContainer failed: %v", err)
+	}
+	if inspect.ID != containers[0].ID {
+		t.Errorf("Expected container %s, got %s", containers[0].ID, inspect.ID)
+	}
+	if inspect.State.Running != (containers[0].State == "running") {
+		t.Errorf("Expected running %v, got %+v", containers[0].State == "running", inspect.State)
+	}
+
+	if _, err := client.InspectContainer(ctx, "does-not-exist"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
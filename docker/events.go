@@ -0,0 +1,65 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Event is a container event from the Docker event stream, such as start, die or oom
+type Event struct {
+	Type   string `json:"Type"`
+	Action string `json:"Action"`
+	Actor  struct {
+		ID         string            `json:"ID"`
+		Attributes map[string]string `json:"Attributes"`
+	} `json:"Actor"`
+	Time     int64 `json:"time"`     // Unix seconds
+	TimeNano int64 `json:"timeNano"` // Unix nanoseconds
+}
+
+// Events streams container events and calls fn for each one until ctx is cancelled,
+// the stream ends or fn fails. It returns nil when the daemon closes the stream.
+func (c *Client) Events(ctx context.Context, fn func(Event) error) error {
+	filters, err := json.Marshal(map[string][]string{"type": {"container"}})
+	if err != nil {
+		return fmt.Errorf("encode filters: %w", err)
+	}
+	url := "http://docker/events?filters=" + url.QueryEscape(string(filters))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("do request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var event Event
+		if err := decoder.Decode(&event); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("decode event: %w", err)
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ContainerState is the detailed state reported by container inspect
+type ContainerState struct {
+	Status     string    `json:"Status"`
+	Running    bool      `json:"Running"`
+	Restarting bool      `json:"Restarting"`
+	OOMKilled  bool      `json:"OOMKilled"`
+	Dead       bool      `json:"Dead"`
+	ExitCode   int       `json:"ExitCode"`
+	Error      string    `json:"Error"`
+	StartedAt  time.Time `json:"StartedAt"`
+	FinishedAt time.Time `json:"FinishedAt"`
+	Health     *Health   `json:"Health,omitempty"` // Nil for containers without a healthcheck
+}
+
+// Health is the healthcheck state of a container
+type Health struct {
+	Status        string      `json:"Status"` // starting, healthy or unhealthy
+	FailingStreak int         `json:"FailingStreak"`
+	Log           []HealthLog `json:"Log"` // Most recent probes, oldest first
+}
+
+// HealthLog is the result of a single healthcheck probe
+type HealthLog struct {
+	Start    time.Time `json:"Start"`
+	End      time.Time `json:"End"`
+	ExitCode int       `json:"ExitCode"`
+	Output   string    `json:"Output"`
+}
+
+// ContainerInspect holds the parts of a container's inspect output duh uses
+type ContainerInspect struct {
+	ID           string         `json:"Id"`
+	Name         string         `json:"Name"`
+	RestartCount int            `json:"RestartCount"`
+	State        ContainerState `json:"State"`
+}
+
+// InspectContainer returns low-level information about a container.
+// It returns ErrNotFound if the container does not exist.
+func (c *Client) InspectContainer(ctx context.Context, containerID string) (*ContainerInspect, error) {
+	url := fmt.Sprintf("http://docker/containers/%s/json", containerID)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	default:
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var inspect ContainerInspect
+	if err := json.NewDecoder(resp.Body).Decode(&inspect); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	return &inspect, nil
+}
@@ -0,0 +1,120 @@
+package docker
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// maxLogLine bounds the size of a single log line returned by ReadLogLines
+const maxLogLine = 64 * 1024
+
+// LogOptions controls which container output ContainerLogs returns
+type LogOptions struct {
+	Follow     bool
+	Tail       int // Number of lines from the end of the log, 0 for all
+	Timestamps bool
+}
+
+// LogLine represents a single line of container output
+type LogLine struct {
+	Stream string `json:"stream"` // stdout or stderr
+	Line   string `json:"line"`
+}
+
+// ContainerLogs returns the raw log stream of a container. The caller must close it.
+// Use ReadLogLines to decode the stream.
+func (c *Client) ContainerLogs(ctx context.Context, containerID string, opts LogOptions) (io.ReadCloser, error) {
+	query := url.Values{}
+	query.Set("stdout", "true")
+	query.Set("stderr", "true")
+	if opts.Follow {
+		query.Set("follow", "true")
+	}
+	if opts.Tail > 0 {
+		query.Set("tail", strconv.Itoa(opts.Tail))
+	}
+	if opts.Timestamps {
+		query.Set("timestamps", "true")
+	}
+	url := fmt.Sprintf("http://docker/containers/%s/logs?%s", containerID, query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return resp.Body, nil
+}
+
+// ReadLogLines decodes a log stream and calls fn for every line. Containers without
+// a TTY produce a multiplexed stream with an 8-byte header per frame; containers with
+// a TTY produce raw output, which is reported as stdout. It returns nil at the end of
+// the stream and stops early with fn's error if fn fails.
+func ReadLogLines(r io.Reader, fn func(LogLine) error) error {
+	br := bufio.NewReader(r)
+
+	header, err := br.Peek(8)
+	if err != nil && len(header) == 0 {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+
+	if !isMultiplexed(header) {
+		return scanLines(br, "stdout", fn)
+	}
+
+	frame := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(br, frame); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		stream := "stdout"
+		if frame[0] == 2 {
+			stream = "stderr"
+		}
+		size := int64(binary.BigEndian.Uint32(frame[4:]))
+
+		if err := scanLines(io.LimitReader(br, size), stream, fn); err != nil {
+			return err
+		}
+	}
+}
+
+// isMultiplexed reports whether a stream starts with a stdcopy frame header
+func isMultiplexed(header []byte) bool {
+	return len(header) == 8 && header[0] <= 2 && header[1] == 0 && header[2] == 0 && header[3] == 0
+}
+
+func scanLines(r io.Reader, stream string, fn func(LogLine) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 4096), maxLogLine)
+	for scanner.Scan() {
+		if err := fn(LogLine{Stream: stream, Line: scanner.Text()}); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
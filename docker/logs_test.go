@@ -0,0 +1,59 @@
+package docker
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+func muxFrame(stream byte, payload string) []byte {
+	frame := make([]byte, 8, 8+len(payload))
+	frame[0] = stream
+	binary.BigEndian.PutUint32(frame[4:], uint32(len(payload)))
+	return append(frame, payload...)
+}
+
+func TestReadLogLinesMultiplexed(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write(muxFrame(1, "hello\nworld\n"))
+	buf.Write(muxFrame(2, "oops\n"))
+
+	var lines []LogLine
+	err := ReadLogLines(&buf, func(l LogLine) error {
+		lines = append(lines, l)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadLogLines failed: %v", err)
+	}
+
+	want := []LogLine{
+		{Stream: "stdout", Line: "hello"},
+		{Stream: "stdout", Line: "world"},
+		{Stream: "stderr", Line: "oops"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("Got %d lines, want %d: %+v", len(lines), len(want), lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("Line %d = %+v, want %+v", i, lines[i], want[i])
+		}
+	}
+}
+
+func TestReadLogLinesRaw(t *testing.T) {
+	var lines []LogLine
+	err := ReadLogLines(strings.NewReader("tty output\nsecond line\n"), func(l LogLine) error {
+		lines = append(lines, l)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadLogLines failed: %v", err)
+	}
+
+	if len(lines) != 2 || lines[0].Line != "tty output" || lines[1].Stream != "stdout" {
+		t.Errorf("Unexpected lines: %+v", lines)
+	}
+}
@@ -3,15 +3,19 @@ package main
 import (
 	"context"
 	"embed"
+	"flag"
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
 	"runtime"
 	"syscall"
 	"time"
 
+	"github.com/yarlson/duh/alert"
 	"github.com/yarlson/duh/docker"
 	"github.com/yarlson/duh/logger"
+	"github.com/yarlson/duh/probe"
 	"github.com/yarlson/duh/server"
 	"github.com/yarlson/duh/service"
 	"github.com/yarlson/duh/store"
@@ -38,12 +42,75 @@ func openBrowser(url string) error {
 	return cmd.Start()
 }
 
+// defaultSchedulesPath keeps schedules in the user's configuration directory
+func defaultSchedulesPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "duh-schedules.json"
+	}
+	return filepath.Join(dir, "duh", "schedules.json")
+}
+
 func main() {
+	alertConfig := flag.String("alerts", "", "path to a JSON file with alert rules and webhooks")
+	idleAfter := flag.Duration("idle-after", 0, "stop containers matching -idle-scope after they stay idle this long (0 disables)")
+	idleScope := flag.String("idle-scope", "", `containers the idle policy applies to, e.g. "project:side OR label:duh.idle-stop"`)
+	idleCPU := flag.Float64("idle-cpu", service.DefaultIdleCPUPercent, "CPU percentage below which a container is idle")
+	idleNetwork := flag.Float64("idle-network", service.DefaultIdleNetworkRate, "network bytes per second at or below which a container is idle")
+	idleDryRun := flag.Bool("idle-dry-run", false, "only list idle containers at /api/idle instead of stopping them")
+	tombstoneTTL := flag.Duration("tombstone-ttl", store.DefaultTombstoneTTL, "how long removed containers stay visible with ?include=removed")
+	critical := flag.String("critical", "", `containers that /api/status reports on besides those labelled duh.critical=true, e.g. "project:shop"`)
+	schedulesPath := flag.String("schedules", defaultSchedulesPath(), "file where scheduled container actions are saved")
+	flag.Parse()
+
 	l := logger.New()
+	if *tombstoneTTL < 0 {
+		l.Fatal("-tombstone-ttl must not be negative")
+	}
 	l.Info("Starting duh...")
 	dockerClient := docker.NewClient()
-	memoryStore := store.NewStore(30 * time.Second)
-	containerService := service.New(dockerClient, memoryStore)
+	memoryStore := store.NewStore(30*time.Second, store.WithTombstoneTTL(*tombstoneTTL))
+
+	prober := probe.NewRunner(memoryStore)
+	serviceOpts := []service.Option{
+		service.WithActionRefresh(),
+		service.WithAutoheal(),
+		service.WithSyncHook(prober.Check),
+	}
+	var alerts *alert.Manager
+	if *alertConfig != "" {
+		cfg, err := alert.LoadConfig(*alertConfig)
+		if err != nil {
+			l.Fatal("Loading alerts failed: %v", err)
+		}
+		alerts = alert.NewManager(cfg)
+		serviceOpts = append(serviceOpts, service.WithSyncHook(alerts.Evaluate))
+		l.Info("Loaded %d alert rules", len(cfg.Rules))
+	}
+	if *idleAfter > 0 {
+		if *idleScope == "" {
+			l.Fatal("-idle-after requires -idle-scope")
+		}
+		scope, err := service.ParseQuery(*idleScope)
+		if err != nil {
+			l.Fatal("Invalid -idle-scope: %v", err)
+		}
+		serviceOpts = append(serviceOpts, service.WithIdleStop(service.IdlePolicy{
+			After:       *idleAfter,
+			CPUPercent:  *idleCPU,
+			NetworkRate: *idleNetwork,
+			Scope:       scope,
+			DryRun:      *idleDryRun,
+		}))
+	}
+	if *critical != "" {
+		filter, err := service.ParseQuery(*critical)
+		if err != nil {
+			l.Fatal("Invalid -critical: %v", err)
+		}
+		serviceOpts = append(serviceOpts, service.WithCritical(filter))
+	}
+	containerService := service.New(dockerClient, memoryStore, serviceOpts...)
 
 	containers, err := containerService.SyncContainers(context.Background())
 	if err != nil {
@@ -57,6 +124,8 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	go containerService.WatchEvents(ctx)
+
 	go func() {
 		ticker := time.NewTicker(10 * time.Second)
 		defer ticker.Stop()
@@ -73,7 +142,13 @@ func main() {
 		}
 	}()
 
-	srv := server.New(containerService, StaticFiles)
+	scheduler, err := service.NewScheduler(containerService, *schedulesPath)
+	if err != nil {
+		l.Fatal("Loading schedules failed: %v", err)
+	}
+	go scheduler.Run(ctx)
+
+	srv := server.New(containerService, StaticFiles, server.WithScheduler(scheduler))
 
 	go func() {
 		if err := srv.ListenAndServe(serverPort); err != nil {
@@ -91,7 +166,12 @@ func main() {
 	<-quit
 
 	l.Info("Shutting down...")
+	if alerts != nil {
+		alerts.Close()
+	}
 	cancel()
+	prober.Close()
+	containerService.Close()
 	memoryStore.Close()
 	l.Info("Server stopped gracefully")
 }
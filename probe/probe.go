@@ -0,0 +1,174 @@
+// Package probe checks that the apps inside containers answer on their published
+// ports, independently of what Docker reports
+package probe
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yarlson/duh/store"
+)
+
+// Labels that opt a container in to probes and tune them
+const (
+	HTTPLabel     = "duh.probe.http"      // Path to GET, e.g. /healthz
+	HTTPPortLabel = "duh.probe.http.port" // Container port for the HTTP probe, the first published TCP port by default
+	TCPLabel      = "duh.probe.tcp"       // Container port to connect to, e.g. 5432
+	IntervalLabel = "duh.probe.interval"  // Time between probes, e.g. 30s
+	TimeoutLabel  = "duh.probe.timeout"   // Time allowed for one probe, e.g. 5s
+)
+
+const (
+	DefaultInterval = 30 * time.Second
+	DefaultTimeout  = 5 * time.Second
+)
+
+// spec is one probe requested by a container's labels
+type spec struct {
+	kind string // store.ProbeHTTP or store.ProbeTCP
+	path string // HTTP probes only
+	port uint16 // Container port, 0 for the first published TCP port
+}
+
+// specs returns the probes requested by a container's labels
+func specs(labels map[string]string) ([]spec, error) {
+	var result []spec
+	if path, ok := labels[HTTPLabel]; ok {
+		s := spec{kind: store.ProbeHTTP, path: "/" + strings.TrimPrefix(strings.TrimSpace(path), "/")}
+		if value, ok := labels[HTTPPortLabel]; ok {
+			port, err := parsePort(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", HTTPPortLabel, err)
+			}
+			s.port = port
+		}
+		result = append(result, s)
+	}
+	if value, ok := labels[TCPLabel]; ok {
+		port, err := parsePort(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", TCPLabel, err)
+		}
+		result = append(result, spec{kind: store.ProbeTCP, port: port})
+	}
+	return result, nil
+}
+
+// unresolved describes the target of a probe whose port is not published
+func (s spec) unresolved() string {
+	port := "*"
+	if s.port != 0 {
+		port = strconv.Itoa(int(s.port))
+	}
+	if s.kind == store.ProbeHTTP {
+		return ":" + port + s.path
+	}
+	return ":" + port
+}
+
+func parsePort(value string) (uint16, error) {
+	port, err := strconv.ParseUint(strings.TrimSpace(value), 10, 16)
+	if err != nil || port == 0 {
+		return 0, fmt.Errorf("invalid port %q", value)
+	}
+	return uint16(port), nil
+}
+
+// labelDuration reads a positive duration label, falling back for missing or
+// invalid values
+func labelDuration(labels map[string]string, key string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(strings.TrimSpace(labels[key]))
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
+
+// address returns the host address a container port is published on
+func address(ports []store.Port, port uint16) (string, error) {
+	published := make([]store.Port, 0, len(ports))
+	for _, p := range ports {
+		if p.Type == "tcp" && p.PublicPort != 0 {
+			published = append(published, p)
+		}
+	}
+	sort.SliceStable(published, func(i, j int) bool {
+		return published[i].PrivatePort < published[j].PrivatePort
+	})
+
+	for _, p := range published {
+		if port != 0 && p.PrivatePort != port {
+			continue
+		}
+		host := p.IP
+		switch host {
+		case "", "0.0.0.0":
+			host = "127.0.0.1"
+		case "::":
+			host = "::1"
+		}
+		return net.JoinHostPort(host, strconv.Itoa(int(p.PublicPort))), nil
+	}
+	if port == 0 {
+		return "", fmt.Errorf("no published tcp port")
+	}
+	return "", fmt.Errorf("port %d/tcp is not published", port)
+}
+
+// checkHTTP sends a GET request and reports the service up for any status below 400.
+// Redirects are not followed.
+func checkHTTP(ctx context.Context, client *http.Client, url string, timeout time.Duration) store.ProbeResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	result := store.ProbeResult{At: start}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	resp, err := client.Do(req)
+	result.LatencyMS = latency(start)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	_ = resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	result.Up = resp.StatusCode < http.StatusBadRequest
+	if !result.Up {
+		result.Error = resp.Status
+	}
+	return result
+}
+
+// checkTCP reports the service up when a connection can be opened
+func checkTCP(ctx context.Context, addr string, timeout time.Duration) store.ProbeResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	result := store.ProbeResult{At: start}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	result.LatencyMS = latency(start)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	_ = conn.Close()
+	result.Up = true
+	return result
+}
+
+func latency(start time.Time) float64 {
+	return float64(time.Since(start).Microseconds()) / 1000
+}
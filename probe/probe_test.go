@@ -0,0 +1,56 @@
+package probe
+
+import (
+	"testing"
+
+	"github.com/yarlson/duh/store"
+)
+
+func TestAddress(t *testing.T) {
+	ports := []store.Port{
+		{PrivatePort: 9000, Type: "tcp"}, // not published
+		{IP: "0.0.0.0", PrivatePort: 8080, PublicPort: 32768, Type: "tcp"},
+		{IP: "::", PrivatePort: 8080, PublicPort: 32768, Type: "tcp"},
+		{IP: "192.168.1.5", PrivatePort: 5432, PublicPort: 5432, Type: "tcp"},
+		{IP: "0.0.0.0", PrivatePort: 53, PublicPort: 5353, Type: "udp"},
+	}
+
+	testCases := []struct {
+		port uint16
+		want string
+	}{
+		{0, "192.168.1.5:5432"},
+		{8080, "127.0.0.1:32768"},
+		{5432, "192.168.1.5:5432"},
+		{53, ""},
+		{9000, ""},
+	}
+
+	for _, tc := range testCases {
+		got, err := address(ports, tc.port)
+		if tc.want == "" {
+			if err == nil {
+				t.Errorf("address(%d) = %q, want an error", tc.port, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("address(%d) = %q, %v, want %q", tc.port, got, err, tc.want)
+		}
+	}
+}
+
+func TestSpecs(t *testing.T) {
+	got, err := specs(map[string]string{HTTPLabel: "healthz", HTTPPortLabel: "8080", TCPLabel: "5432"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []spec{{kind: store.ProbeHTTP, path: "/healthz", port: 8080}, {kind: store.ProbeTCP, port: 5432}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("specs() = %+v, want %+v", got, want)
+	}
+
+	if _, err := specs(map[string]string{TCPLabel: "postgres"}); err == nil {
+		t.Error("Expected an error for an invalid port")
+	}
+}
@@ -0,0 +1,203 @@
+package probe
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/yarlson/duh/logger"
+	"github.com/yarlson/duh/store"
+)
+
+// HistorySize is the number of results kept per probe
+const HistorySize = 20
+
+// Store receives probe results for the container record
+type Store interface {
+	SetProbes(id string, probes []store.Probe) bool
+}
+
+// Runner probes containers labelled with duh.probe.http or duh.probe.tcp on their
+// published ports and records the results on the container
+type Runner struct {
+	store  Store
+	client *http.Client
+	now    func() time.Time
+
+	mu     sync.Mutex
+	states map[string]*containerState
+	wg     sync.WaitGroup
+}
+
+// containerState tracks the probes of one container
+type containerState struct {
+	probes  []store.Probe
+	next    time.Time
+	running bool
+}
+
+// Option configures a Runner
+type Option func(*Runner)
+
+// WithClock replaces time.Now, for tests
+func WithClock(now func() time.Time) Option {
+	return func(r *Runner) {
+		r.now = now
+	}
+}
+
+// NewRunner creates a runner that records results in the store
+func NewRunner(s Store, opts ...Option) *Runner {
+	r := &Runner{
+		store: s,
+		client: &http.Client{
+			// Every probe opens a new connection, as a client would after a restart
+			Transport: &http.Transport{DisableKeepAlives: true},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		now:    time.Now,
+		states: make(map[string]*containerState),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Check starts the probes that are due, in the background. It is meant to run after
+// every sync, so probe intervals are rounded up to the sync interval.
+func (r *Runner) Check(ctx context.Context, containers []store.ContainerData) {
+	now := r.now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	seen := make(map[string]bool)
+	for _, c := range containers {
+		probeSpecs, err := specs(c.Labels)
+		if err != nil {
+			logger.New().Warn("Ignoring probes of %s: %v", c.ID, err)
+			continue
+		}
+		if len(probeSpecs) == 0 {
+			continue
+		}
+		seen[c.ID] = true
+
+		state := r.states[c.ID]
+		if state == nil {
+			state = &containerState{}
+			r.states[c.ID] = state
+		}
+		if state.running {
+			continue
+		}
+		if c.State != "running" {
+			r.pause(c.ID, state)
+			continue
+		}
+		if now.Before(state.next) {
+			continue
+		}
+
+		state.running = true
+		state.next = now.Add(labelDuration(c.Labels, IntervalLabel, DefaultInterval))
+		r.wg.Add(1)
+		go r.probe(ctx, c, probeSpecs, labelDuration(c.Labels, TimeoutLabel, DefaultTimeout))
+	}
+
+	for id := range r.states {
+		if !seen[id] {
+			delete(r.states, id)
+		}
+	}
+}
+
+// Close waits for running probes to finish
+func (r *Runner) Close() {
+	r.wg.Wait()
+}
+
+// probe runs every probe of a container and records the results
+func (r *Runner) probe(ctx context.Context, c store.ContainerData, probeSpecs []spec, timeout time.Duration) {
+	defer r.wg.Done()
+
+	type outcome struct {
+		target string
+		result store.ProbeResult
+	}
+	outcomes := make([]outcome, len(probeSpecs))
+	var wg sync.WaitGroup
+	for i, s := range probeSpecs {
+		addr, err := address(c.Ports, s.port)
+		if err != nil {
+			outcomes[i] = outcome{target: s.unresolved(), result: store.ProbeResult{At: time.Now(), Error: err.Error()}}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, s spec) {
+			defer wg.Done()
+			if s.kind == store.ProbeHTTP {
+				url := "http://" + addr + s.path
+				outcomes[i] = outcome{target: url, result: checkHTTP(ctx, r.client, url, timeout)}
+				return
+			}
+			outcomes[i] = outcome{target: addr, result: checkTCP(ctx, addr, timeout)}
+		}(i, s)
+	}
+	wg.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state := r.states[c.ID]
+	if state == nil {
+		return // The container went away meanwhile
+	}
+	state.running = false
+
+	probes := make([]store.Probe, len(probeSpecs))
+	for i, s := range probeSpecs {
+		probe := store.Probe{Type: s.kind, Target: outcomes[i].target, Status: store.ProbeDown}
+		if outcomes[i].result.Up {
+			probe.Status = store.ProbeUp
+		}
+		// Carry on the history of the same check, dropping the oldest results
+		for _, previous := range state.probes {
+			if previous.Type == probe.Type && previous.Target == probe.Target {
+				probe.History = append(probe.History, previous.History...)
+				break
+			}
+		}
+		probe.History = append(probe.History, outcomes[i].result)
+		if len(probe.History) > HistorySize {
+			probe.History = probe.History[len(probe.History)-HistorySize:]
+		}
+		probes[i] = probe
+	}
+	state.probes = probes
+	r.store.SetProbes(c.ID, probes)
+}
+
+// pause marks the probes of a stopped container as paused, keeping their history.
+// It is called with r.mu held.
+func (r *Runner) pause(id string, state *containerState) {
+	changed := false
+	probes := make([]store.Probe, len(state.probes))
+	for i, probe := range state.probes {
+		if probe.Status != store.ProbePaused {
+			probe.Status = store.ProbePaused
+			changed = true
+		}
+		probes[i] = probe
+	}
+	if !changed {
+		return
+	}
+	state.probes = probes
+	state.next = time.Time{} // Probe again as soon as the container is back
+	r.store.SetProbes(id, probes)
+}
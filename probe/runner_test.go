@@ -0,0 +1,132 @@
+package probe
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yarlson/duh/store"
+)
+
+type fakeStore struct {
+	mu     sync.Mutex
+	probes map[string][]store.Probe
+}
+
+func (s *fakeStore) SetProbes(id string, probes []store.Probe) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.probes[id] = probes
+	return true
+}
+
+func publishedPort(t *testing.T, addr string, private uint16) store.Port {
+	t.Helper()
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store.Port{IP: host, PrivatePort: private, PublicPort: uint16(public), Type: "tcp"}
+}
+
+func TestRunnerCheck(t *testing.T) {
+	healthy := true
+	app := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" || !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer app.Close()
+
+	db, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbAddr := db.Addr().String()
+	go func() {
+		for {
+			conn, err := db.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+
+	now := time.Now()
+	results := &fakeStore{probes: make(map[string][]store.Probe)}
+	runner := NewRunner(results, WithClock(func() time.Time { return now }))
+
+	containers := []store.ContainerData{
+		{
+			ID:     "web",
+			State:  "running",
+			Labels: map[string]string{HTTPLabel: "healthz", HTTPPortLabel: "8080", TCPLabel: "5432"},
+			Ports: []store.Port{
+				publishedPort(t, app.Listener.Addr().String(), 8080),
+				publishedPort(t, dbAddr, 5432),
+			},
+		},
+		{ID: "plain", State: "running"},
+		{ID: "hidden", State: "running", Labels: map[string]string{TCPLabel: "6379"}},
+	}
+
+	runner.Check(context.Background(), containers)
+	runner.Close()
+
+	web := results.probes["web"]
+	if len(web) != 2 {
+		t.Fatalf("Expected two probes for web, got %+v", web)
+	}
+	if web[0].Type != store.ProbeHTTP || web[0].Status != store.ProbeUp || web[0].Target != app.URL+"/healthz" {
+		t.Errorf("Unexpected http probe %+v", web[0])
+	}
+	if web[1].Type != store.ProbeTCP || web[1].Status != store.ProbeUp || web[1].Target != dbAddr {
+		t.Errorf("Unexpected tcp probe %+v", web[1])
+	}
+	if _, probed := results.probes["plain"]; probed {
+		t.Error("Expected containers without probe labels to be left alone")
+	}
+	if hidden := results.probes["hidden"]; len(hidden) != 1 || hidden[0].Status != store.ProbeDown ||
+		hidden[0].History[0].Error != "port 6379/tcp is not published" {
+		t.Errorf("Expected an unpublished port to be reported down, got %+v", hidden)
+	}
+
+	// Not due yet
+	healthy = false
+	now = now.Add(10 * time.Second)
+	runner.Check(context.Background(), containers)
+	runner.Close()
+	if len(results.probes["web"][0].History) != 1 {
+		t.Fatal("Expected no probe before the interval passed")
+	}
+
+	now = now.Add(DefaultInterval)
+	runner.Check(context.Background(), containers)
+	runner.Close()
+	web = results.probes["web"]
+	if web[0].Status != store.ProbeDown || len(web[0].History) != 2 {
+		t.Fatalf("Expected the http probe to go down with history, got %+v", web[0])
+	}
+	if last := web[0].History[1]; last.StatusCode != http.StatusServiceUnavailable || last.Up {
+		t.Errorf("Unexpected result %+v", last)
+	}
+
+	// A stopped container keeps its history
+	containers[0].State = "exited"
+	runner.Check(context.Background(), containers)
+	runner.Close()
+	web = results.probes["web"]
+	if web[0].Status != store.ProbePaused || len(web[0].History) != 2 {
+		t.Errorf("Expected paused probes with history, got %+v", web[0])
+	}
+}
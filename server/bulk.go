@@ -0,0 +1,52 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/yarlson/duh/service"
+)
+
+// maxRequestBody bounds the size of JSON request bodies
+const maxRequestBody = 1 << 20
+
+// bulkResponse reports the per-container outcome of a bulk action
+type bulkResponse struct {
+	Action    string               `json:"action"`
+	Results   []service.BulkResult `json:"results"`
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+	Skipped   int                  `json:"skipped"`
+}
+
+func (s *Server) handleBulkActions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req service.BulkRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody)).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := s.service.BulkAction(r.Context(), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	response := bulkResponse{Action: req.Action, Results: results}
+	for _, result := range results {
+		switch result.Status {
+		case service.BulkSucceeded:
+			response.Succeeded++
+		case service.BulkFailed:
+			response.Failed++
+		case service.BulkSkipped:
+			response.Skipped++
+		}
+	}
+	writeJSON(w, response)
+}
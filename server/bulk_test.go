@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yarlson/duh/service"
+	"github.com/yarlson/duh/store"
+)
+
+func TestHandleBulkActions(t *testing.T) {
+	mockDocker := &DockerClientMock{
+		StartContainerFunc: func(ctx context.Context, id string) error {
+			return nil
+		},
+	}
+
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(store.ContainerData{ID: "a", State: "exited", Image: "redis:7"})
+	memoryStore.Update(store.ContainerData{ID: "b", State: "running", Image: "redis:7"})
+	srv := New(service.New(mockDocker, memoryStore), testFiles)
+
+	body := `{"action":"start","selector":{"image":"redis:*"}}`
+	w := httptest.NewRecorder()
+	srv.handleBulkActions(w, httptest.NewRequest("POST", "/api/containers/actions", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response bulkResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Succeeded != 1 || response.Skipped != 1 || response.Failed != 0 || len(response.Results) != 2 {
+		t.Errorf("Unexpected bulk response %+v", response)
+	}
+
+	for _, body := range []string{`{"action":"explode","ids":["a"]}`, `{"action":"start"}`, `not json`} {
+		w = httptest.NewRecorder()
+		srv.handleBulkActions(w, httptest.NewRequest("POST", "/api/containers/actions", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Body %s: expected status code %d, got %d", body, http.StatusBadRequest, w.Code)
+		}
+	}
+}
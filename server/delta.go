@@ -0,0 +1,82 @@
+package server
+
+import (
+	"hash/fnv"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yarlson/duh/store"
+)
+
+// containerChanges is the response to GET /api/containers?since=<version>. When Full
+// is set the requested version was too old and Containers holds the complete list.
+type containerChanges struct {
+	Version    uint64                `json:"version"`
+	Full       bool                  `json:"full"`
+	Containers []store.ContainerData `json:"containers"`
+	Removed    []string              `json:"removed"`
+}
+
+func (s *Server) handleContainerChanges(w http.ResponseWriter, r *http.Request, since string) {
+	version, err := strconv.ParseUint(since, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid since version", http.StatusBadRequest)
+		return
+	}
+
+	changed, removed, current, ok := s.service.Changes(version)
+	if notModified(w, r, current) {
+		return
+	}
+
+	response := containerChanges{
+		Version:    current,
+		Containers: changed,
+		Removed:    removed,
+	}
+	if !ok {
+		response.Full = true
+		response.Containers, response.Version = s.service.Snapshot()
+		response.Removed = []string{}
+		w.Header().Set("ETag", etag(response.Version, r.URL.Query()))
+	}
+	writeJSON(w, response)
+}
+
+// etag identifies a response by the store version and the query that shaped it, so
+// that differently filtered or sorted lists of the same version do not match
+func etag(version uint64, query url.Values) string {
+	tag := strconv.FormatUint(version, 10)
+	if len(query) > 0 {
+		normalized := make(url.Values, len(query))
+		for key, values := range query {
+			values = append([]string(nil), values...)
+			sort.Strings(values)
+			normalized[key] = values
+		}
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(normalized.Encode()))
+		tag += "-" + strconv.FormatUint(h.Sum64(), 36)
+	}
+	return `"` + tag + `"`
+}
+
+// notModified sets the ETag for the given store version and request query and
+// answers 304 Not Modified when the client already has it
+func notModified(w http.ResponseWriter, r *http.Request, version uint64) bool {
+	tag := etag(version, r.URL.Query())
+	w.Header().Set("ETag", tag)
+	w.Header().Set("Cache-Control", "no-cache")
+
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == tag || candidate == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/yarlson/duh/service"
+	"github.com/yarlson/duh/store"
+)
+
+func TestHandleContainersETag(t *testing.T) {
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(store.ContainerData{ID: "web", State: "running"})
+	srv := New(service.New(&DockerClientMock{}, memoryStore), testFiles)
+
+	w := httptest.NewRecorder()
+	srv.handleContainers(w, httptest.NewRequest("GET", "/api/containers", nil))
+	tag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || tag == "" {
+		t.Fatalf("Expected 200 with ETag, got %d and %q", w.Code, tag)
+	}
+
+	req := httptest.NewRequest("GET", "/api/containers", nil)
+	req.Header.Set("If-None-Match", tag)
+	w = httptest.NewRecorder()
+	srv.handleContainers(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("Expected status %d, got %d", http.StatusNotModified, w.Code)
+	}
+
+	memoryStore.Update(store.ContainerData{ID: "web", State: "exited"})
+	w = httptest.NewRecorder()
+	srv.handleContainers(w, req)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == tag {
+		t.Errorf("Expected 200 with new ETag after change, got %d and %q", w.Code, w.Header().Get("ETag"))
+	}
+}
+
+func TestHandleContainersETagVariesByQuery(t *testing.T) {
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(store.ContainerData{ID: "web", State: "running"})
+	memoryStore.Update(store.ContainerData{ID: "db", State: "exited"})
+	srv := New(service.New(&DockerClientMock{}, memoryStore), testFiles)
+
+	get := func(target, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		srv.handleContainers(w, req)
+		return w
+	}
+
+	// Both lists come from the same store version
+	running := get("/api/containers?state=running", "").Header().Get("ETag")
+	exited := get("/api/containers?state=exited", "").Header().Get("ETag")
+	all := get("/api/containers", "").Header().Get("ETag")
+	if running == exited || running == all || exited == all {
+		t.Fatalf("Expected distinct ETags per query, got %q, %q and %q", running, exited, all)
+	}
+
+	if w := get("/api/containers?state=exited", running); w.Code != http.StatusOK {
+		t.Errorf("Expected status %d for another query's ETag, got %d", http.StatusOK, w.Code)
+	}
+	if w := get("/api/containers?state=running", running); w.Code != http.StatusNotModified {
+		t.Errorf("Expected status %d for the same query, got %d", http.StatusNotModified, w.Code)
+	}
+
+	// Parameter order does not change the ETag
+	if a, b := get("/api/containers?state=running&sort=name", "").Header().Get("ETag"),
+		get("/api/containers?sort=name&state=running", "").Header().Get("ETag"); a != b {
+		t.Errorf("Expected reordered query to keep its ETag, got %q and %q", a, b)
+	}
+}
+
+func TestHandleContainersSince(t *testing.T) {
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(store.ContainerData{ID: "web", State: "running"})
+	memoryStore.Update(store.ContainerData{ID: "db", State: "running"})
+	since := memoryStore.Version()
+	memoryStore.Update(store.ContainerData{ID: "db", State: "exited"})
+
+	srv := New(service.New(&DockerClientMock{}, memoryStore), testFiles)
+
+	w := httptest.NewRecorder()
+	srv.handleContainers(w, httptest.NewRequest("GET", "/api/containers?since="+strconv.FormatUint(since, 10), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var changes containerChanges
+	if err := json.NewDecoder(w.Body).Decode(&changes); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if changes.Full || len(changes.Containers) != 1 || changes.Containers[0].ID != "db" {
+		t.Errorf("Expected delta with only db, got %+v", changes)
+	}
+	if changes.Version != memoryStore.Version() {
+		t.Errorf("Expected version %d, got %d", memoryStore.Version(), changes.Version)
+	}
+
+	// A version older than the store falls back to a full list
+	w = httptest.NewRecorder()
+	srv.handleContainers(w, httptest.NewRequest("GET", "/api/containers?since=1", nil))
+	if err := json.NewDecoder(w.Body).Decode(&changes); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !changes.Full || len(changes.Containers) != 2 {
+		t.Errorf("Expected full list of 2 containers, got %+v", changes)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleContainers(w, httptest.NewRequest("GET", "/api/containers?since=abc", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for bad version, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestHandleContainersIncludeRemoved(t *testing.T) {
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(store.ContainerData{ID: "web", State: "running"})
+	memoryStore.Update(store.ContainerData{ID: "old", State: "exited"})
+	memoryStore.Remove("old")
+
+	srv := New(service.New(&DockerClientMock{}, memoryStore), testFiles)
+
+	var containers []store.ContainerData
+	w := httptest.NewRecorder()
+	srv.handleContainers(w, httptest.NewRequest("GET", "/api/containers", nil))
+	if err := json.NewDecoder(w.Body).Decode(&containers); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(containers) != 1 {
+		t.Errorf("Expected removed containers to be hidden by default, got %d", len(containers))
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleContainers(w, httptest.NewRequest("GET", "/api/containers?include=removed", nil))
+	if err := json.NewDecoder(w.Body).Decode(&containers); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(containers) != 2 || containers[1].ID != "old" || !containers[1].Removed {
+		t.Errorf("Expected tombstone for old after live containers, got %+v", containers)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleContainer(w, httptest.NewRequest("GET", "/api/containers/old", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d without include, got %d", http.StatusNotFound, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleContainer(w, httptest.NewRequest("GET", "/api/containers/old?include=removed", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d with include, got %d", http.StatusOK, w.Code)
+	}
+}
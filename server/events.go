@@ -0,0 +1,225 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yarlson/duh/store"
+)
+
+const (
+	sseHeartbeatInterval = 15 * time.Second
+	sseRetry             = 3 * time.Second
+	sseEventSnapshot     = "snapshot"
+)
+
+// eventFilter limits an event stream to a set of container IDs and states.
+// Empty sets match everything.
+type eventFilter struct {
+	ids    map[string]bool
+	states map[string]bool
+}
+
+// newEventFilter reads comma-separated or repeated id and state query parameters
+func newEventFilter(query url.Values) eventFilter {
+	return eventFilter{
+		ids:    queryValues(query, "id"),
+		states: queryValues(query, "state"),
+	}
+}
+
+func (f eventFilter) match(c store.ContainerData) bool {
+	if len(f.ids) > 0 && !f.ids[c.ID] {
+		return false
+	}
+	if len(f.states) > 0 && !f.states[c.State] {
+		return false
+	}
+	return true
+}
+
+func queryValues(query url.Values, key string) map[string]bool {
+	values := make(map[string]bool)
+	for _, v := range query[key] {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values[part] = true
+			}
+		}
+	}
+	return values
+}
+
+// lastEventID returns the event ID a reconnecting client has already seen
+func lastEventID(r *http.Request) (uint64, bool) {
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = r.URL.Query().Get("last_event_id")
+	}
+	if raw == "" {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// handleEvents streams container changes as Server-Sent Events. A new client receives
+// a snapshot event with the full (filtered) container list followed by incremental
+// added, updated, stats-updated and removed events. A client reconnecting with
+// Last-Event-ID is replayed the events it missed, or a fresh snapshot when they are
+// no longer retained. Containers that stop matching the filter are sent as removed.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ctx := r.Context()
+	filter := newEventFilter(r.URL.Query())
+
+	// Subscribe before reading the snapshot or history so that no change is missed;
+	// events already covered are skipped by sequence number below.
+	events := s.service.Subscribe(ctx, 0)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	stream := &eventStream{
+		w:       w,
+		filter:  filter,
+		visible: make(map[string]bool),
+	}
+
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds()); err != nil {
+		return
+	}
+
+	if id, ok := lastEventID(r); ok {
+		if missed, ok := s.service.EventsSince(id); ok {
+			stream.resumed = true
+			stream.lastSeq = id
+			for _, ev := range missed {
+				if err := stream.send(ev); err != nil {
+					return
+				}
+			}
+		}
+	}
+
+	if !stream.resumed {
+		containers, seq := s.service.Snapshot()
+		if err := stream.snapshot(containers, seq); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				// Either the client went away or it was dropped as a slow consumer.
+				// In both cases it reconnects with Last-Event-ID.
+				return
+			}
+			if err := stream.send(ev); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// eventStream writes filtered store events to a single SSE client
+type eventStream struct {
+	w       io.Writer
+	filter  eventFilter
+	lastSeq uint64
+	// visible tracks whether the client currently knows about a container
+	visible map[string]bool
+	// resumed streams have no snapshot, so unseen containers may be known to the client
+	resumed bool
+}
+
+func (e *eventStream) snapshot(containers []store.ContainerData, seq uint64) error {
+	matched := make([]store.ContainerData, 0, len(containers))
+	for _, c := range containers {
+		visible := e.filter.match(c)
+		e.visible[c.ID] = visible
+		if visible {
+			matched = append(matched, c)
+		}
+	}
+	e.lastSeq = seq
+	return writeSSE(e.w, seq, sseEventSnapshot, matched)
+}
+
+func (e *eventStream) send(ev store.Event) error {
+	if ev.Seq <= e.lastSeq {
+		return nil
+	}
+	e.lastSeq = ev.Seq
+
+	id := ev.Container.ID
+	visible, known := e.visible[id]
+	wasVisible := visible || (!known && e.resumed)
+
+	typ := ev.Type
+	switch {
+	case ev.Type == store.EventRemoved:
+		e.visible[id] = false
+		if !wasVisible {
+			return nil
+		}
+	case !e.filter.match(ev.Container):
+		e.visible[id] = false
+		if !wasVisible {
+			return nil
+		}
+		typ = store.EventRemoved
+	default:
+		e.visible[id] = true
+		if !wasVisible {
+			typ = store.EventAdded
+		}
+	}
+
+	return writeSSE(e.w, ev.Seq, string(typ), ev.Container)
+}
+
+func writeSSE(w io.Writer, id uint64, event string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, data)
+	return err
+}
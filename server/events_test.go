@@ -0,0 +1,129 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yarlson/duh/service"
+	"github.com/yarlson/duh/store"
+)
+
+type sseMessage struct {
+	id    string
+	event string
+	data  string
+}
+
+// readSSE parses messages from an event stream, skipping comments and retry hints
+func readSSE(t *testing.T, r *bufio.Reader) sseMessage {
+	t.Helper()
+	var msg sseMessage
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read event stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			if msg.event != "" {
+				return msg
+			}
+		case strings.HasPrefix(line, "id: "):
+			msg.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			msg.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			msg.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func openEventStream(t *testing.T, url string, header http.Header) (*bufio.Reader, func()) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open event stream: %v", err)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected Content-Type text/event-stream, got %q", ct)
+	}
+	return bufio.NewReader(resp.Body), func() {
+		cancel()
+		_ = resp.Body.Close()
+	}
+}
+
+func TestHandleEvents(t *testing.T) {
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(store.ContainerData{ID: "web", State: "running"})
+	memoryStore.Update(store.ContainerData{ID: "db", State: "exited"})
+
+	srv := New(service.New(&DockerClientMock{}, memoryStore), testFiles)
+	ts := httptest.NewServer(http.HandlerFunc(srv.handleEvents))
+	defer ts.Close()
+
+	stream, closeStream := openEventStream(t, ts.URL+"?state=running", nil)
+	defer closeStream()
+
+	msg := readSSE(t, stream)
+	if msg.event != "snapshot" {
+		t.Fatalf("Expected snapshot event, got %q", msg.event)
+	}
+	var snapshot []store.ContainerData
+	if err := json.Unmarshal([]byte(msg.data), &snapshot); err != nil {
+		t.Fatalf("Failed to decode snapshot: %v", err)
+	}
+	if len(snapshot) != 1 || snapshot[0].ID != "web" {
+		t.Errorf("Expected snapshot with only web, got %+v", snapshot)
+	}
+
+	// Filtered-out containers are not sent, matching ones are sent as added
+	memoryStore.Update(store.ContainerData{ID: "db", State: "dead"})
+	memoryStore.Update(store.ContainerData{ID: "db", State: "running"})
+	if msg = readSSE(t, stream); msg.event != "added" || !strings.Contains(msg.data, `"db"`) {
+		t.Errorf("Expected added event for db, got %+v", msg)
+	}
+
+	// A container leaving the filter is sent as removed
+	memoryStore.Update(store.ContainerData{ID: "web", State: "exited"})
+	if msg = readSSE(t, stream); msg.event != "removed" || !strings.Contains(msg.data, `"web"`) {
+		t.Errorf("Expected removed event for web, got %+v", msg)
+	}
+}
+
+func TestHandleEventsResume(t *testing.T) {
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(store.ContainerData{ID: "web", State: "running"})
+	_, seq := memoryStore.Snapshot()
+	memoryStore.UpdateStats("web", &store.Stats{})
+
+	srv := New(service.New(&DockerClientMock{}, memoryStore), testFiles)
+	ts := httptest.NewServer(http.HandlerFunc(srv.handleEvents))
+	defer ts.Close()
+
+	header := http.Header{}
+	header.Set("Last-Event-ID", strconv.FormatUint(seq, 10))
+	stream, closeStream := openEventStream(t, ts.URL, header)
+	defer closeStream()
+
+	msg := readSSE(t, stream)
+	if msg.event != "stats-updated" {
+		t.Errorf("Expected replayed stats-updated event, got %q", msg.event)
+	}
+}
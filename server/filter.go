@@ -0,0 +1,47 @@
+package server
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/yarlson/duh/service"
+)
+
+// containerFilters builds list filters from the query string: q searches names, image
+// and ID; state takes a comma separated list; label may be repeated; image and
+// project match a single value; query is a filter expression parsed by
+// service.ParseQuery.
+func containerFilters(query url.Values) ([]service.Filter, error) {
+	var filters []service.Filter
+
+	if text := strings.TrimSpace(query.Get("q")); text != "" {
+		filters = append(filters, service.MatchText(text))
+	}
+	if states := queryValues(query, "state"); len(states) > 0 {
+		var list []string
+		for state := range states {
+			list = append(list, state)
+		}
+		filters = append(filters, service.MatchState(list...))
+	}
+	for _, label := range query["label"] {
+		if label != "" {
+			filters = append(filters, service.MatchLabel(label))
+		}
+	}
+	if image := query.Get("image"); image != "" {
+		filters = append(filters, service.MatchImage(image))
+	}
+	if project := query.Get("project"); project != "" {
+		filters = append(filters, service.MatchProject(project))
+	}
+	if expr := strings.TrimSpace(query.Get("query")); expr != "" {
+		filter, err := service.ParseQuery(expr)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+
+	return filters, nil
+}
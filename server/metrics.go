@@ -0,0 +1,158 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yarlson/duh/logger"
+	"github.com/yarlson/duh/store"
+)
+
+// metricStates are reported by duh_container_state, one series per state
+var metricStates = []string{
+	"running", "created", "restarting", "paused", "exited", "dead",
+	store.StateStarting, store.StateStopping, store.StateError,
+}
+
+// handleMetrics serves container stats and duh's own counters in the Prometheus text
+// exposition format. Everything comes from the store and the service counters, so a
+// scrape never calls Docker.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	bw := bufio.NewWriter(w)
+	defer func() { _ = bw.Flush() }()
+
+	containers, _ := s.service.Snapshot()
+	sort.Slice(containers, func(i, j int) bool {
+		return containers[i].ID < containers[j].ID
+	})
+
+	m := &metricWriter{w: bw}
+	m.family("duh_container_state", "gauge", "Container state, 1 for the current state")
+	for _, c := range containers {
+		for _, state := range metricStates {
+			value := 0.0
+			if c.State == state {
+				value = 1
+			}
+			m.sample("duh_container_state", containerLabels(c, "state", state), value)
+		}
+	}
+
+	statsFamilies := []struct {
+		name, typ, help string
+		value           func(*store.Stats) float64
+	}{
+		{"duh_container_cpu_percent", "gauge", "CPU usage in percent of one core",
+			func(s *store.Stats) float64 { return s.CPU.Usage }},
+		{"duh_container_memory_usage_bytes", "gauge", "Memory usage in bytes",
+			func(s *store.Stats) float64 { return float64(s.Memory.Usage) }},
+		{"duh_container_memory_limit_bytes", "gauge", "Memory limit in bytes",
+			func(s *store.Stats) float64 { return float64(s.Memory.Limit) }},
+		{"duh_container_network_receive_bytes_total", "counter", "Bytes received over all interfaces",
+			func(s *store.Stats) float64 { return float64(s.Network.RxBytes) }},
+		{"duh_container_network_transmit_bytes_total", "counter", "Bytes sent over all interfaces",
+			func(s *store.Stats) float64 { return float64(s.Network.TxBytes) }},
+		{"duh_container_blkio_read_bytes_total", "counter", "Bytes read from block devices",
+			func(s *store.Stats) float64 { return float64(s.BlockIO.ReadBytes) }},
+		{"duh_container_blkio_write_bytes_total", "counter", "Bytes written to block devices",
+			func(s *store.Stats) float64 { return float64(s.BlockIO.WriteBytes) }},
+	}
+	for _, family := range statsFamilies {
+		m.family(family.name, family.typ, family.help)
+		for _, c := range containers {
+			if c.Stats != nil {
+				m.sample(family.name, containerLabels(c), family.value(c.Stats))
+			}
+		}
+	}
+
+	sync := s.service.SyncMetrics()
+	m.family("duh_sync_duration_seconds", "summary", "Time spent syncing containers and stats from Docker")
+	m.sample("duh_sync_duration_seconds_sum", nil, sync.SyncSeconds)
+	m.sample("duh_sync_duration_seconds_count", nil, float64(sync.Syncs))
+	m.family("duh_sync_last_duration_seconds", "gauge", "Duration of the most recent sync")
+	m.sample("duh_sync_last_duration_seconds", nil, sync.LastSync.Seconds())
+	m.family("duh_sync_failures_total", "counter", "Syncs that failed to list containers")
+	m.sample("duh_sync_failures_total", nil, float64(sync.SyncFailures))
+
+	ops := make([]string, 0, len(sync.DockerErrors))
+	for op := range sync.DockerErrors {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+	m.family("duh_docker_errors_total", "counter", "Failed Docker API calls by operation")
+	for _, op := range ops {
+		m.sample("duh_docker_errors_total", []string{"operation", op}, float64(sync.DockerErrors[op]))
+	}
+
+	if m.err != nil {
+		logger.New().Warn("Error writing metrics: %v", m.err)
+	}
+}
+
+// containerLabels returns the identifying labels of a container followed by extra
+// name/value pairs
+func containerLabels(c store.ContainerData, extra ...string) []string {
+	name := ""
+	if len(c.Names) > 0 {
+		name = strings.TrimPrefix(c.Names[0], "/")
+	}
+	project := ""
+	if c.Compose != nil {
+		project = c.Compose.Project
+	}
+	return append([]string{"id", c.ID, "name", name, "image", c.Image, "project", project}, extra...)
+}
+
+// metricWriter writes the exposition format, remembering the first write error
+type metricWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (m *metricWriter) family(name, typ, help string) {
+	m.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sample writes one series; labels are name/value pairs
+func (m *metricWriter) sample(name string, labels []string, value float64) {
+	var b strings.Builder
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(labels[i])
+			b.WriteString(`="`)
+			b.WriteString(escapeLabelValue(labels[i+1]))
+			b.WriteByte('"')
+		}
+		b.WriteByte('}')
+	}
+	m.printf("%s %s\n", b.String(), strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func (m *metricWriter) printf(format string, args ...interface{}) {
+	if m.err != nil {
+		return
+	}
+	_, m.err = fmt.Fprintf(m.w, format, args...)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelEscaper.Replace(v)
+}
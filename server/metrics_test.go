@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yarlson/duh/docker"
+	"github.com/yarlson/duh/service"
+	"github.com/yarlson/duh/store"
+)
+
+func TestHandleMetrics(t *testing.T) {
+	mockDocker := &DockerClientMock{
+		ListContainersFunc: func(ctx context.Context, all bool) ([]docker.Container, error) {
+			return nil, errors.New("daemon unavailable")
+		},
+	}
+
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(store.ContainerData{
+		ID:      "abc",
+		Names:   []string{"/web"},
+		Image:   `shop/"web"`,
+		State:   "running",
+		Compose: &store.ComposeInfo{Project: "shop", Service: "web"},
+	})
+	stats := &store.Stats{}
+	stats.CPU.Usage = 12.5
+	stats.Memory.Usage = 1024
+	stats.Network.RxBytes = 42
+	stats.BlockIO.WriteBytes = 7
+	memoryStore.UpdateStats("abc", stats)
+	memoryStore.Update(store.ContainerData{ID: "def", Names: []string{"/db"}, Image: "postgres", State: "exited"})
+
+	containerService := service.New(mockDocker, memoryStore)
+	_ = containerService.Sync(context.Background())
+	srv := New(containerService, testFiles)
+
+	w := httptest.NewRecorder()
+	srv.handleMetrics(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected content type %q", w.Header().Get("Content-Type"))
+	}
+
+	labels := `{id="abc",name="web",image="shop/\"web\"",project="shop"`
+	want := []string{
+		"# TYPE duh_container_cpu_percent gauge",
+		"duh_container_cpu_percent" + labels + "} 12.5",
+		"duh_container_memory_usage_bytes" + labels + "} 1024",
+		"duh_container_network_receive_bytes_total" + labels + "} 42",
+		"duh_container_blkio_write_bytes_total" + labels + "} 7",
+		"duh_container_state" + labels + `,state="running"} 1`,
+		`duh_container_state{id="def",name="db",image="postgres",project="",state="exited"} 1`,
+		"duh_sync_duration_seconds_count 1",
+		"duh_sync_failures_total 1",
+		`duh_docker_errors_total{operation="list"} 1`,
+	}
+	for _, line := range want {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Metrics missing %q", line)
+		}
+	}
+
+	// Containers without stats only report their state
+	if strings.Contains(body, `duh_container_cpu_percent{id="def"`) {
+		t.Error("Expected no stats series for a container without stats")
+	}
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/yarlson/duh/service"
+)
+
+// projectActionResponse reports the per-service steps of a project action
+type projectActionResponse struct {
+	Project string                `json:"project"`
+	Action  string                `json:"action"`
+	Steps   []service.ProjectStep `json:"steps"`
+}
+
+func (s *Server) handleProjects(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, s.service.Projects())
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleProject(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/api/projects/")
+	if name == "" {
+		http.Error(w, "Project name required", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		project, exists := s.service.Project(name)
+		if !exists {
+			http.Error(w, "Project not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, project)
+	case http.MethodPost:
+		query := r.URL.Query()
+		opts, err := stopOptionsFromQuery(query)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		action := query.Get("action")
+		steps, err := s.service.ProjectAction(r.Context(), name, action, opts)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, projectActionResponse{Project: name, Action: action, Steps: steps})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
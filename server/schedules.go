@@ -0,0 +1,78 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/yarlson/duh/service"
+)
+
+func (s *Server) handleSchedules(w http.ResponseWriter, r *http.Request) {
+	if s.scheduler == nil {
+		http.Error(w, "Scheduling is disabled", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, s.scheduler.Schedules())
+	case http.MethodPost:
+		var schedule service.Schedule
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody)).Decode(&schedule); err != nil {
+			http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		created, err := s.scheduler.CreateSchedule(schedule)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		writeJSON(w, created)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleSchedule(w http.ResponseWriter, r *http.Request) {
+	if s.scheduler == nil {
+		http.Error(w, "Scheduling is disabled", http.StatusNotFound)
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/api/schedules/")
+	if id == "" {
+		http.Error(w, "Schedule ID required", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		schedule, err := s.scheduler.Schedule(id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, schedule)
+	case http.MethodPut:
+		var schedule service.Schedule
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody)).Decode(&schedule); err != nil {
+			http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		updated, err := s.scheduler.UpdateSchedule(id, schedule)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, updated)
+	case http.MethodDelete:
+		if err := s.scheduler.DeleteSchedule(id); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yarlson/duh/service"
+	"github.com/yarlson/duh/store"
+)
+
+func TestHandleSchedules(t *testing.T) {
+	containerService := service.New(&DockerClientMock{}, store.NewStore(time.Minute))
+	scheduler, err := service.NewScheduler(containerService, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := New(containerService, testFiles, WithScheduler(scheduler))
+
+	body := `{"name":"evening","cron":"0 19 * * mon-fri","action":"stop","selector":{"project":"heavy"}}`
+	w := httptest.NewRecorder()
+	srv.handleSchedules(w, httptest.NewRequest("POST", "/api/schedules", strings.NewReader(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created service.Schedule
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if created.ID == "" || created.NextRun == nil || created.Selector.Project != "heavy" {
+		t.Errorf("Unexpected schedule %+v", created)
+	}
+
+	body = `{"name":"evening","cron":"0 20 * * mon-fri","action":"stop","selector":{"project":"heavy"}}`
+	w = httptest.NewRecorder()
+	srv.handleSchedule(w, httptest.NewRequest("PUT", "/api/schedules/"+created.ID, strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var updated service.Schedule
+	if err := json.NewDecoder(w.Body).Decode(&updated); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if updated.ID != created.ID || updated.Cron != "0 20 * * mon-fri" || updated.NextRun.Hour() != 20 {
+		t.Errorf("Unexpected updated schedule %+v", updated)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleSchedules(w, httptest.NewRequest("GET", "/api/schedules", nil))
+	var schedules []service.Schedule
+	if err := json.NewDecoder(w.Body).Decode(&schedules); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(schedules) != 1 {
+		t.Errorf("Expected one schedule, got %+v", schedules)
+	}
+
+	for _, body := range []string{`{"cron":"bogus","action":"stop","ids":["a"]}`, `not json`} {
+		w = httptest.NewRecorder()
+		srv.handleSchedules(w, httptest.NewRequest("POST", "/api/schedules", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Body %s: expected status code %d, got %d", body, http.StatusBadRequest, w.Code)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleSchedule(w, httptest.NewRequest("DELETE", "/api/schedules/"+created.ID, nil))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status code %d, got %d", http.StatusNoContent, w.Code)
+	}
+	w = httptest.NewRecorder()
+	srv.handleSchedule(w, httptest.NewRequest("GET", "/api/schedules/"+created.ID, nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -4,15 +4,19 @@ import (
 	"context"
 	"embed"
 	"encoding/json"
+	"errors"
 	"io"
 	"io/fs"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/yarlson/duh/docker"
 	"github.com/yarlson/duh/logger"
 	"github.com/yarlson/duh/service"
+	"github.com/yarlson/duh/store"
 )
 
 //go:generate moq -out docker_moq_test.go . DockerClient
@@ -20,23 +24,42 @@ import (
 // DockerClient defines the interface for Docker operations
 type DockerClient interface {
 	ListContainers(ctx context.Context, all bool) ([]docker.Container, error)
+	GetContainer(ctx context.Context, id string) (*docker.Container, error)
 	GetContainerStats(ctx context.Context, id string) (*docker.ContainerStats, error)
 	StartContainer(ctx context.Context, id string) error
-	StopContainer(ctx context.Context, id string) error
+	StopContainer(ctx context.Context, id string, opts docker.StopOptions) error
+	ContainerLogs(ctx context.Context, id string, opts docker.LogOptions) (io.ReadCloser, error)
+	InspectContainer(ctx context.Context, id string) (*docker.ContainerInspect, error)
+	Events(ctx context.Context, fn func(docker.Event) error) error
 }
 
 // Server represents the HTTP server
 type Server struct {
-	service  *service.ContainerService
-	staticFS embed.FS
+	service   *service.ContainerService
+	scheduler *service.Scheduler
+	staticFS  embed.FS
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithScheduler serves the scheduler's schedules at /api/schedules
+func WithScheduler(scheduler *service.Scheduler) Option {
+	return func(s *Server) {
+		s.scheduler = scheduler
+	}
 }
 
 // New creates a new HTTP server
-func New(service *service.ContainerService, staticFS embed.FS) *Server {
-	return &Server{
+func New(service *service.ContainerService, staticFS embed.FS, opts ...Option) *Server {
+	s := &Server{
 		service:  service,
 		staticFS: staticFS,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // ListenAndServe starts the HTTP server
@@ -47,6 +70,19 @@ func (s *Server) ListenAndServe(addr string) error {
 	// API endpoints
 	mux.HandleFunc("/api/containers", s.handleContainers)
 	mux.HandleFunc("/api/containers/", s.handleContainer)
+	mux.HandleFunc("/api/containers/actions", s.handleBulkActions)
+	mux.HandleFunc("/api/events", s.handleEvents)
+	mux.HandleFunc("/api/ws", s.handleWebSocket)
+	mux.HandleFunc("/api/operations", s.handleOperations)
+	mux.HandleFunc("/api/projects", s.handleProjects)
+	mux.HandleFunc("/api/projects/", s.handleProject)
+	mux.HandleFunc("/api/autoheal", s.handleAutoheal)
+	mux.HandleFunc("/api/idle", s.handleIdle)
+	mux.HandleFunc("/api/schedules", s.handleSchedules)
+	mux.HandleFunc("/api/schedules/", s.handleSchedule)
+	mux.HandleFunc("/api/status", s.handleStatus)
+	mux.HandleFunc("/api/usage", s.handleUsage)
+	mux.HandleFunc("/metrics", s.handleMetrics)
 
 	// Get the dist subdirectory from the embedded files
 	distFS, err := fs.Sub(s.staticFS, "www/dist")
@@ -97,7 +133,35 @@ func (s *Server) ListenAndServe(addr string) error {
 func (s *Server) handleContainers(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		containers := s.service.List()
+		filters, err := containerFilters(r.URL.Query())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		order, err := service.ParseSort(r.URL.Query().Get("sort"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if since := r.URL.Query().Get("since"); since != "" {
+			if len(filters) > 0 || r.URL.Query().Has("sort") {
+				http.Error(w, "Filters and sort cannot be combined with since", http.StatusBadRequest)
+				return
+			}
+			s.handleContainerChanges(w, r, since)
+			return
+		}
+		containers, version := s.service.Snapshot()
+		if notModified(w, r, version) {
+			return
+		}
+		if includeRemoved(r) {
+			containers = append(containers, s.service.Removed()...)
+		}
+		containers = service.FilterContainers(containers, filters...)
+		if r.URL.Query().Has("sort") {
+			service.SortContainers(containers, order...)
+		}
 		writeJSON(w, containers)
 	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -114,6 +178,9 @@ func (s *Server) handleContainer(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
 		container, exists := s.service.Get(id)
+		if !exists && includeRemoved(r) {
+			container, exists = s.findRemoved(id)
+		}
 		if !exists {
 			http.Error(w, "Container not found", http.StatusNotFound)
 			return
@@ -121,9 +188,14 @@ func (s *Server) handleContainer(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, container)
 
 	case http.MethodPost:
-		action := r.URL.Query().Get("action")
-		if err := s.handleContainerAction(r.Context(), id, action); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		query := r.URL.Query()
+		opts, err := stopOptionsFromQuery(query)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if err := s.handleContainerAction(r.Context(), id, query.Get("action"), opts); err != nil {
+			writeError(w, err)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
@@ -133,12 +205,12 @@ func (s *Server) handleContainer(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (s *Server) handleContainerAction(ctx context.Context, id, action string) error {
+func (s *Server) handleContainerAction(ctx context.Context, id, action string, opts docker.StopOptions) error {
 	switch action {
 	case "start":
 		return s.service.StartContainer(ctx, id)
 	case "stop":
-		return s.service.StopContainer(ctx, id)
+		return s.service.StopContainer(ctx, id, opts)
 	default:
 		return &httpError{
 			Status:  http.StatusBadRequest,
@@ -147,6 +219,36 @@ func (s *Server) handleContainerAction(ctx context.Context, id, action string) e
 	}
 }
 
+// stopOptionsFromQuery reads the optional timeout (seconds) and signal of a stop action
+func stopOptionsFromQuery(query url.Values) (docker.StopOptions, error) {
+	opts := docker.StopOptions{Signal: query.Get("signal")}
+	if value := query.Get("timeout"); value != "" {
+		timeout, err := strconv.Atoi(value)
+		if err != nil || timeout < 0 {
+			return opts, &httpError{
+				Status:  http.StatusBadRequest,
+				Message: "Invalid timeout",
+			}
+		}
+		opts.Timeout = &timeout
+	}
+	return opts, nil
+}
+
+// includeRemoved reports whether the request opted in to tombstones with ?include=removed
+func includeRemoved(r *http.Request) bool {
+	return queryValues(r.URL.Query(), "include")["removed"]
+}
+
+func (s *Server) findRemoved(id string) (store.ContainerData, bool) {
+	for _, tombstone := range s.service.Removed() {
+		if tombstone.ID == id {
+			return tombstone, true
+		}
+	}
+	return store.ContainerData{}, false
+}
+
 type httpError struct {
 	Status  int
 	Message string
@@ -156,6 +258,66 @@ func (e *httpError) Error() string {
 	return e.Message
 }
 
+func (s *Server) handleOperations(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, s.service.Operations())
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleAutoheal(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, s.service.AutohealLog())
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleIdle(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, s.service.IdleReport())
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// writeError responds with the status carried by an httpError, 409 for conflicting
+// container actions, 400 for invalid service requests, queries, schedules and usage requests,
+// 404 for unknown projects and schedules, or 500 for any other error
+func writeError(w http.ResponseWriter, err error) {
+	var httpErr *httpError
+	if errors.As(err, &httpErr) {
+		http.Error(w, httpErr.Message, httpErr.Status)
+		return
+	}
+	var conflict *service.ConflictError
+	if errors.As(err, &conflict) {
+		http.Error(w, conflict.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, service.ErrInvalidAction) || errors.Is(err, service.ErrNoTargets) ||
+		errors.Is(err, service.ErrInvalidSort) || errors.Is(err, service.ErrInvalidSchedule) ||
+		errors.Is(err, service.ErrInvalidUsage) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var queryErr *service.QueryError
+	if errors.As(err, &queryErr) {
+		http.Error(w, queryErr.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, service.ErrProjectNotFound) || errors.Is(err, service.ErrScheduleNotFound) ||
+		errors.Is(err, docker.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
 func writeJSON(w http.ResponseWriter, v interface{}) {
 	l := logger.New()
 	w.Header().Set("Content-Type", "application/json")
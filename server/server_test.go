@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -84,7 +86,7 @@ func TestServerContainerActions(t *testing.T) {
 		StartContainerFunc: func(ctx context.Context, id string) error {
 			return nil
 		},
-		StopContainerFunc: func(ctx context.Context, id string) error {
+		StopContainerFunc: func(ctx context.Context, id string, opts docker.StopOptions) error {
 			return nil
 		},
 		ListContainersFunc: func(ctx context.Context, all bool) ([]docker.Container, error) {
@@ -106,7 +108,7 @@ func TestServerContainerActions(t *testing.T) {
 	server := New(service, testFiles)
 
 	// Test start container
-	req := httptest.NewRequest("POST", "/api/containers/test1?action=start", nil)
+	req := httptest.NewRequest("POST", "/api/containers/test?action=start", nil)
 	w := httptest.NewRecorder()
 
 	server.handleContainer(w, req)
@@ -116,7 +118,7 @@ func TestServerContainerActions(t *testing.T) {
 	}
 
 	// Test stop container
-	req = httptest.NewRequest("POST", "/api/containers/test1?action=stop", nil)
+	req = httptest.NewRequest("POST", "/api/containers/test?action=stop", nil)
 	w = httptest.NewRecorder()
 
 	server.handleContainer(w, req)
@@ -125,12 +127,49 @@ func TestServerContainerActions(t *testing.T) {
 		t.Errorf("Expected status code %d, got %d", http.StatusNoContent, w.Code)
 	}
 
+	// Test stop container with timeout and signal
+	req = httptest.NewRequest("POST", "/api/containers/test?action=stop&timeout=30&signal=SIGINT", nil)
+	w = httptest.NewRecorder()
+
+	server.handleContainer(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status code %d, got %d", http.StatusNoContent, w.Code)
+	}
+
+	// Test invalid timeout
+	req = httptest.NewRequest("POST", "/api/containers/test?action=stop&timeout=soon", nil)
+	w = httptest.NewRecorder()
+
+	server.handleContainer(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	// Unknown containers are not found and leave the store untouched
+	req = httptest.NewRequest("POST", "/api/containers/bogus?action=start", nil)
+	w = httptest.NewRecorder()
+
+	server.handleContainer(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+	if _, exists := store.Get("bogus"); exists {
+		t.Error("Expected no record for an unknown container")
+	}
+
 	// Verify mock calls
 	if len(mockDocker.StartContainerCalls()) != 1 {
 		t.Error("Expected one call to StartContainer")
 	}
-	if len(mockDocker.StopContainerCalls()) != 1 {
-		t.Error("Expected one call to StopContainer")
+	stopCalls := mockDocker.StopContainerCalls()
+	if len(stopCalls) != 2 {
+		t.Fatalf("Expected two calls to StopContainer, got %d", len(stopCalls))
+	}
+	if opts := stopCalls[1].Opts; opts.Timeout == nil || *opts.Timeout != 30 || opts.Signal != "SIGINT" {
+		t.Errorf("Expected stop options to be passed through, got %+v", opts)
 	}
 }
 
@@ -238,3 +277,183 @@ func TestHandleContainer(t *testing.T) {
 		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
 	}
 }
+
+func TestHandleContainerActionConflict(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	mockDocker := &DockerClientMock{
+		StartContainerFunc: func(ctx context.Context, id string) error {
+			close(started)
+			<-release
+			return nil
+		},
+		ListContainersFunc: func(ctx context.Context, all bool) ([]docker.Container, error) {
+			return []docker.Container{{ID: "test", State: "exited"}}, nil
+		},
+	}
+
+	srv := New(service.New(mockDocker, store.NewStore(time.Minute)), testFiles)
+
+	go func() {
+		w := httptest.NewRecorder()
+		srv.handleContainer(w, httptest.NewRequest("POST", "/api/containers/test?action=start", nil))
+	}()
+	<-started
+	defer close(release)
+
+	w := httptest.NewRecorder()
+	srv.handleOperations(w, httptest.NewRequest("GET", "/api/operations", nil))
+	var ops []service.Operation
+	if err := json.NewDecoder(w.Body).Decode(&ops); err != nil {
+		t.Fatalf("Failed to decode operations: %v", err)
+	}
+	if len(ops) != 1 || ops[0].Action != "start" {
+		t.Errorf("Expected one in-flight start operation, got %+v", ops)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleContainer(w, httptest.NewRequest("POST", "/api/containers/test?action=stop", nil))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status code %d, got %d", http.StatusConflict, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleContainer(w, httptest.NewRequest("POST", "/api/containers/test?action=bogus", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for invalid action, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestHandleProjects(t *testing.T) {
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(store.ContainerData{
+		ID:      "web",
+		State:   "running",
+		Compose: &store.ComposeInfo{Project: "shop", Service: "web", Number: 1},
+	})
+	srv := New(service.New(&DockerClientMock{}, memoryStore), testFiles)
+
+	w := httptest.NewRecorder()
+	srv.handleProjects(w, httptest.NewRequest("GET", "/api/projects", nil))
+	var projects []service.Project
+	if err := json.NewDecoder(w.Body).Decode(&projects); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(projects) != 1 || projects[0].Name != "shop" || projects[0].State != service.ProjectUp {
+		t.Errorf("Unexpected projects %+v", projects)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleProject(w, httptest.NewRequest("GET", "/api/projects/shop", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleProject(w, httptest.NewRequest("GET", "/api/projects/missing", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestHandleProjectAction(t *testing.T) {
+	mockDocker := &DockerClientMock{
+		StopContainerFunc: func(ctx context.Context, id string, opts docker.StopOptions) error {
+			return nil
+		},
+	}
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(store.ContainerData{
+		ID:      "web",
+		State:   "running",
+		Compose: &store.ComposeInfo{Project: "shop", Service: "web", Number: 1},
+	})
+	srv := New(service.New(mockDocker, memoryStore), testFiles)
+
+	w := httptest.NewRecorder()
+	srv.handleProject(w, httptest.NewRequest("POST", "/api/projects/shop?action=stop", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response projectActionResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Steps) != 1 || response.Steps[0].Service != "web" || response.Steps[0].Status != service.BulkSucceeded {
+		t.Errorf("Unexpected steps %+v", response.Steps)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleProject(w, httptest.NewRequest("POST", "/api/projects/missing?action=stop", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleProject(w, httptest.NewRequest("POST", "/api/projects/shop?action=pause", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestHandleContainersFilters(t *testing.T) {
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(store.ContainerData{ID: "api", Names: []string{"/payments-api"}, Image: "payments:1", State: "running",
+		Labels: map[string]string{"team": "payments"}})
+	memoryStore.Update(store.ContainerData{ID: "cache", Names: []string{"/payments-cache"}, Image: "redis:7", State: "running",
+		Labels: map[string]string{"team": "payments"}})
+	memoryStore.Update(store.ContainerData{ID: "search", Names: []string{"/search"}, Image: "elasticsearch:8", State: "exited"})
+	srv := New(service.New(&DockerClientMock{}, memoryStore), testFiles)
+
+	testCases := []struct {
+		query string
+		want  int
+	}{
+		{"", 3},
+		{"?q=payments", 2},
+		{"?state=exited,created", 1},
+		{"?label=team=payments&image=redis", 1},
+		{"?query=" + url.QueryEscape("state:running label:team=payments -image:redis"), 1},
+	}
+
+	for _, tc := range testCases {
+		w := httptest.NewRecorder()
+		srv.handleContainers(w, httptest.NewRequest("GET", "/api/containers"+tc.query, nil))
+		var containers []store.ContainerData
+		if err := json.NewDecoder(w.Body).Decode(&containers); err != nil {
+			t.Fatalf("%s: failed to decode response: %v", tc.query, err)
+		}
+		if len(containers) != tc.want {
+			t.Errorf("%s: expected %d containers, got %d", tc.query, tc.want, len(containers))
+		}
+	}
+
+	w := httptest.NewRecorder()
+	srv.handleContainers(w, httptest.NewRequest("GET", "/api/containers?query=colour:red", nil))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "unknown field") {
+		t.Errorf("Expected 400 with a parse error, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandleContainersSort(t *testing.T) {
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(store.ContainerData{ID: "b", Names: []string{"/beta"}, State: "running"})
+	memoryStore.Update(store.ContainerData{ID: "a", Names: []string{"/alpha"}, State: "exited"})
+	srv := New(service.New(&DockerClientMock{}, memoryStore), testFiles)
+
+	w := httptest.NewRecorder()
+	srv.handleContainers(w, httptest.NewRequest("GET", "/api/containers?sort=name", nil))
+	var containers []store.ContainerData
+	if err := json.NewDecoder(w.Body).Decode(&containers); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(containers) != 2 || containers[0].ID != "a" {
+		t.Errorf("Expected containers sorted by name, got %+v", containers)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleContainers(w, httptest.NewRequest("GET", "/api/containers?sort=colour", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/yarlson/duh/service"
+)
+
+// handleStatus reports the health of the critical containers for uptime monitors.
+// It responds 200 while the status is ok or degraded and 503 when it is down, as
+// JSON or, with ?format=text, as plain text starting with the status.
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	status := s.service.Status()
+	code := http.StatusOK
+	if status.Status == service.StatusDown {
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Cache-Control", "no-store")
+
+	if r.URL.Query().Get("format") != "text" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		writeJSON(w, status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(code)
+	_, _ = fmt.Fprintln(w, status.Status)
+	if status.Error != "" {
+		_, _ = fmt.Fprintf(w, "docker: %s\n", status.Error)
+	}
+	for _, failing := range status.Failing {
+		_, _ = fmt.Fprintf(w, "%s: %s\n", failing.Name, failing.Reason)
+	}
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yarlson/duh/service"
+	"github.com/yarlson/duh/store"
+)
+
+func TestHandleStatus(t *testing.T) {
+	critical := map[string]string{service.CriticalLabel: "true"}
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(store.ContainerData{ID: "1", Names: []string{"/web"}, State: "running", Labels: critical})
+	srv := New(service.New(&DockerClientMock{}, memoryStore), testFiles)
+
+	w := httptest.NewRecorder()
+	srv.handleStatus(w, httptest.NewRequest("GET", "/api/status", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	var status service.Status
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if status.Status != service.StatusOK || status.Critical != 1 {
+		t.Errorf("Unexpected status %+v", status)
+	}
+
+	memoryStore.Update(store.ContainerData{ID: "1", Names: []string{"/web"}, State: "exited", Labels: critical})
+	w = httptest.NewRecorder()
+	srv.handleStatus(w, httptest.NewRequest("GET", "/api/status?format=text", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status code %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	if got := w.Body.String(); got != "down\nweb: exited\n" {
+		t.Errorf("Unexpected text status %q", got)
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("Unexpected content type %q", got)
+	}
+}
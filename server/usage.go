@@ -0,0 +1,57 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/yarlson/duh/service"
+)
+
+// usageResponse lists usage groups, largest first by the metric
+type usageResponse struct {
+	By     string               `json:"by"`
+	Metric string               `json:"metric"`
+	Groups []service.UsageGroup `json:"groups"`
+}
+
+// handleUsage aggregates container usage. by selects the grouping (image, project,
+// host or label:<key>), metric the ordering (cpu, memory, network or blkio) and top
+// the number of contributors per group; the container filters of /api/containers
+// narrow the containers included.
+func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	filters, err := containerFilters(query)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	req := service.UsageRequest{
+		By:      query.Get("by"),
+		Metric:  query.Get("metric"),
+		Filters: filters,
+	}
+	if req.By == "" {
+		req.By = service.UsageByHost
+	}
+	if req.Metric == "" {
+		req.Metric = service.UsageMemory
+	}
+	if value := query.Get("top"); value != "" {
+		if req.Top, err = strconv.Atoi(value); err != nil || req.Top < 0 {
+			http.Error(w, "Invalid top", http.StatusBadRequest)
+			return
+		}
+	}
+
+	groups, err := s.service.Usage(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, usageResponse{By: req.By, Metric: req.Metric, Groups: groups})
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yarlson/duh/service"
+	"github.com/yarlson/duh/store"
+)
+
+func TestHandleUsage(t *testing.T) {
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(store.ContainerData{ID: "1", Image: "nginx", State: "running", Compose: &store.ComposeInfo{Project: "shop"}})
+	memoryStore.Update(store.ContainerData{ID: "2", Image: "redis", State: "exited", Compose: &store.ComposeInfo{Project: "shop"}})
+	memoryStore.Update(store.ContainerData{ID: "3", Image: "nginx", State: "running"})
+	srv := New(service.New(&DockerClientMock{}, memoryStore), testFiles)
+
+	w := httptest.NewRecorder()
+	srv.handleUsage(w, httptest.NewRequest("GET", "/api/usage?by=project&project=shop&state=running", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response usageResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.By != "project" || response.Metric != "memory" || len(response.Groups) != 1 ||
+		response.Groups[0].Key != "shop" || response.Groups[0].Containers != 1 {
+		t.Errorf("Unexpected usage response %+v", response)
+	}
+
+	for _, query := range []string{"by=owner", "metric=disk", "top=-1", "query=(state:running"} {
+		w = httptest.NewRecorder()
+		srv.handleUsage(w, httptest.NewRequest("GET", "/api/usage?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Query %s: expected status code %d, got %d", query, http.StatusBadRequest, w.Code)
+		}
+	}
+}
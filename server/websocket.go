@@ -0,0 +1,316 @@
+package server
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Minimal RFC 6455 implementation covering what the /api/ws endpoint needs:
+// the server side handshake, unfragmented and fragmented text messages, and
+// ping, pong and close control frames. Extensions and subprotocols are not supported.
+
+const (
+	websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+
+	wsCloseNormal        = 1000
+	wsCloseGoingAway     = 1001
+	wsCloseProtocolError = 1002
+	wsCloseTooBig        = 1009
+	wsCloseTryAgainLater = 1013
+
+	wsMaxMessageSize = 64 * 1024
+	wsWriteTimeout   = 10 * time.Second
+)
+
+var (
+	errWSClosed      = errors.New("websocket closed")
+	errWSProtocol    = errors.New("websocket protocol error")
+	errWSMessageSize = errors.New("websocket message too big")
+)
+
+// wsConn is a server side WebSocket connection
+type wsConn struct {
+	conn    net.Conn
+	br      *bufio.Reader
+	writeMu sync.Mutex
+	closed  bool
+
+	// onPong is called from ReadMessage for every pong frame received
+	onPong func()
+}
+
+// upgradeWebSocket performs the opening handshake and takes over the connection
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	if r.Method != http.MethodGet {
+		return nil, &httpError{Status: http.StatusMethodNotAllowed, Message: "Method not allowed"}
+	}
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		return nil, &httpError{Status: http.StatusBadRequest, Message: "WebSocket upgrade required"}
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return nil, &httpError{Status: http.StatusUpgradeRequired, Message: "Unsupported WebSocket version"}
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return nil, &httpError{Status: http.StatusBadRequest, Message: "Missing Sec-WebSocket-Key"}
+	}
+	if !sameOrigin(r) {
+		return nil, &httpError{Status: http.StatusForbidden, Message: "Cross-origin WebSocket connections are not allowed"}
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, &httpError{Status: http.StatusInternalServerError, Message: "WebSocket unsupported"}
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("hijack connection: %w", err)
+	}
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + websocketAccept(key) + "\r\n\r\n"
+
+	_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if _, err := conn.Write([]byte(response)); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("write handshake: %w", err)
+	}
+
+	return &wsConn{conn: conn, br: rw.Reader}, nil
+}
+
+// sameOrigin reports whether a browser request comes from a page served by duh itself.
+// Browsers do not apply CORS to WebSocket connections, so without this check any page
+// could control containers through /api/ws. Requests without an Origin header come
+// from non-browser clients and are allowed.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+func websocketAccept(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func headerContains(header http.Header, name, token string) bool {
+	for _, v := range header.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SetReadDeadline sets the deadline for the next frame read
+func (c *wsConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// ReadMessage returns the next text or binary message. Ping frames are answered
+// and close frames are acknowledged transparently; after a close frame it returns
+// errWSClosed.
+func (c *wsConn) ReadMessage() (byte, []byte, error) {
+	var (
+		opcode  byte
+		message []byte
+	)
+
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch op {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case wsOpPong:
+			if c.onPong != nil {
+				c.onPong()
+			}
+			continue
+		case wsOpClose:
+			code := wsCloseNormal
+			if len(payload) >= 2 {
+				code = int(binary.BigEndian.Uint16(payload))
+			}
+			_ = c.CloseWithCode(code, "")
+			return 0, nil, errWSClosed
+		case wsOpText, wsOpBinary:
+			if message != nil {
+				return 0, nil, c.fail(wsCloseProtocolError, errWSProtocol)
+			}
+			opcode = op
+			message = append([]byte{}, payload...)
+		case wsOpContinuation:
+			if message == nil {
+				return 0, nil, c.fail(wsCloseProtocolError, errWSProtocol)
+			}
+			message = append(message, payload...)
+		default:
+			return 0, nil, c.fail(wsCloseProtocolError, errWSProtocol)
+		}
+
+		if len(message) > wsMaxMessageSize {
+			return 0, nil, c.fail(wsCloseTooBig, errWSMessageSize)
+		}
+		if fin {
+			return opcode, message, nil
+		}
+	}
+}
+
+func (c *wsConn) readFrame() (bool, byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin := header[0]&0x80 != 0
+	opcode := header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+
+	if header[0]&0x70 != 0 || !masked {
+		// No extensions are negotiated and clients must mask every frame
+		return false, 0, nil, c.fail(wsCloseProtocolError, errWSProtocol)
+	}
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	if opcode >= wsOpClose && (length > 125 || !fin) {
+		return false, 0, nil, c.fail(wsCloseProtocolError, errWSProtocol)
+	}
+	if length > wsMaxMessageSize {
+		return false, 0, nil, c.fail(wsCloseTooBig, errWSMessageSize)
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return fin, opcode, payload, nil
+}
+
+// WriteMessage sends a single unfragmented message
+func (c *wsConn) WriteMessage(opcode byte, payload []byte) error {
+	return c.writeFrame(opcode, payload)
+}
+
+// Ping sends a ping control frame
+func (c *wsConn) Ping() error {
+	return c.writeFrame(wsOpPing, nil)
+}
+
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closed {
+		return errWSClosed
+	}
+
+	header := make([]byte, 2, 10)
+	header[0] = 0x80 | opcode
+	switch n := len(payload); {
+	case n <= 125:
+		header[1] = byte(n)
+	case n <= 0xFFFF:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	_ = c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if _, err := c.conn.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// CloseWithCode sends a close frame and closes the underlying connection
+func (c *wsConn) CloseWithCode(code int, reason string) error {
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	payload = append(payload, reason...)
+	if len(payload) > 125 {
+		payload = payload[:125]
+	}
+	_ = c.writeFrame(wsOpClose, payload)
+	return c.Close()
+}
+
+// Close closes the underlying connection without a close handshake
+func (c *wsConn) Close() error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.conn.Close()
+}
+
+func (c *wsConn) fail(code int, err error) error {
+	_ = c.CloseWithCode(code, err.Error())
+	return err
+}
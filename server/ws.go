@@ -0,0 +1,317 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/yarlson/duh/docker"
+	"github.com/yarlson/duh/logger"
+	"github.com/yarlson/duh/store"
+)
+
+const (
+	wsPingInterval   = 30 * time.Second
+	wsPongWait       = 60 * time.Second
+	wsSendQueueSize  = 256
+	wsDefaultLogTail = 100
+
+	wsTopicContainers = "containers"
+	wsTopicStats      = "stats"
+	wsTopicLogs       = "logs"
+)
+
+// wsRequest is a message sent by a WebSocket client. Type is one of subscribe,
+// unsubscribe or action; ID is echoed back in the reply.
+type wsRequest struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	Topic       string `json:"topic,omitempty"`
+	ContainerID string `json:"container_id,omitempty"`
+	Action      string `json:"action,omitempty"`
+	Tail        int    `json:"tail,omitempty"`
+	Timeout     *int   `json:"timeout,omitempty"`
+	Signal      string `json:"signal,omitempty"`
+}
+
+// wsReply answers a single wsRequest
+type wsReply struct {
+	Type  string `json:"type"`
+	ID    string `json:"id"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// wsMessage carries pushed data for a subscription
+type wsMessage struct {
+	Type        string      `json:"type"`
+	Event       string      `json:"event,omitempty"`
+	Seq         uint64      `json:"seq,omitempty"`
+	ContainerID string      `json:"container_id,omitempty"`
+	Error       string      `json:"error,omitempty"`
+	Data        interface{} `json:"data,omitempty"`
+}
+
+// wsSession holds the subscriptions of a single WebSocket connection
+type wsSession struct {
+	srv    *Server
+	conn   *wsConn
+	ctx    context.Context
+	cancel context.CancelFunc
+	send   chan []byte
+
+	mu            sync.Mutex
+	containers    bool
+	containersSeq uint64
+	statsAll      bool
+	stats         map[string]bool
+	logs          map[string]context.CancelFunc
+}
+
+// handleWebSocket serves /api/ws. Clients subscribe to the containers, stats and
+// logs topics and issue container actions over the same connection; every request
+// is answered with a reply carrying the request ID. Stats and log messages are
+// dropped when the client cannot keep up, while a client that falls behind on
+// container changes is disconnected so that it reconnects and resyncs.
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	session := &wsSession{
+		srv:    s,
+		conn:   conn,
+		ctx:    ctx,
+		cancel: cancel,
+		send:   make(chan []byte, wsSendQueueSize),
+		stats:  make(map[string]bool),
+		logs:   make(map[string]context.CancelFunc),
+	}
+
+	go session.writeLoop()
+	go session.eventLoop()
+	session.readLoop()
+}
+
+func (ws *wsSession) readLoop() {
+	defer ws.cancel()
+
+	_ = ws.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	ws.conn.onPong = func() {
+		_ = ws.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	}
+
+	for {
+		_, payload, err := ws.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = ws.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+
+		var req wsRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			ws.reply(wsRequest{}, errors.New("invalid message: "+err.Error()))
+			continue
+		}
+		ws.handle(req)
+	}
+}
+
+func (ws *wsSession) writeLoop() {
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case msg := <-ws.send:
+			if err := ws.conn.WriteMessage(wsOpText, msg); err != nil {
+				ws.cancel()
+				_ = ws.conn.Close()
+				return
+			}
+		case <-ping.C:
+			if err := ws.conn.Ping(); err != nil {
+				ws.cancel()
+				_ = ws.conn.Close()
+				return
+			}
+		case <-ws.ctx.Done():
+			_ = ws.conn.CloseWithCode(wsCloseGoingAway, "")
+			return
+		}
+	}
+}
+
+// eventLoop forwards store changes to the containers and stats subscriptions
+func (ws *wsSession) eventLoop() {
+	events := ws.srv.service.Subscribe(ws.ctx, 0)
+	for ev := range events {
+		ws.mu.Lock()
+		switch {
+		case ev.Type == store.EventStatsUpdated:
+			if ws.statsAll || ws.stats[ev.Container.ID] {
+				ws.enqueue(wsMessage{
+					Type:        wsTopicStats,
+					Seq:         ev.Seq,
+					ContainerID: ev.Container.ID,
+					Data:        ev.Container.Stats,
+				}, true)
+			}
+		case ws.containers && ev.Seq > ws.containersSeq:
+			ws.enqueue(wsMessage{
+				Type:  wsTopicContainers,
+				Event: string(ev.Type),
+				Seq:   ev.Seq,
+				Data:  ev.Container,
+			}, false)
+		}
+		ws.mu.Unlock()
+	}
+
+	// The store drops subscribers that fall behind; make the client resync
+	if ws.ctx.Err() == nil {
+		_ = ws.conn.CloseWithCode(wsCloseTryAgainLater, "slow consumer")
+		ws.cancel()
+	}
+}
+
+func (ws *wsSession) handle(req wsRequest) {
+	switch req.Type {
+	case "subscribe":
+		ws.reply(req, ws.subscribe(req))
+	case "unsubscribe":
+		ws.reply(req, ws.unsubscribe(req))
+	case "action":
+		if req.ContainerID == "" {
+			ws.reply(req, errors.New("container_id required"))
+			return
+		}
+		go func() {
+			ws.reply(req, ws.srv.handleContainerAction(ws.ctx, req.ContainerID, req.Action, docker.StopOptions{Timeout: req.Timeout, Signal: req.Signal}))
+		}()
+	default:
+		ws.reply(req, errors.New("unknown request type: "+req.Type))
+	}
+}
+
+func (ws *wsSession) subscribe(req wsRequest) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	switch req.Topic {
+	case wsTopicContainers:
+		containers, seq := ws.srv.service.Snapshot()
+		ws.containers = true
+		ws.containersSeq = seq
+		ws.enqueue(wsMessage{
+			Type:  wsTopicContainers,
+			Event: sseEventSnapshot,
+			Seq:   seq,
+			Data:  containers,
+		}, false)
+	case wsTopicStats:
+		if req.ContainerID == "" {
+			ws.statsAll = true
+		} else {
+			ws.stats[req.ContainerID] = true
+		}
+	case wsTopicLogs:
+		if req.ContainerID == "" {
+			return errors.New("container_id required")
+		}
+		if _, exists := ws.logs[req.ContainerID]; exists {
+			return nil
+		}
+		tail := req.Tail
+		if tail <= 0 {
+			tail = wsDefaultLogTail
+		}
+		ctx, cancel := context.WithCancel(ws.ctx)
+		ws.logs[req.ContainerID] = cancel
+		go ws.streamLogs(ctx, req.ContainerID, tail)
+	default:
+		return errors.New("unknown topic: " + req.Topic)
+	}
+	return nil
+}
+
+func (ws *wsSession) unsubscribe(req wsRequest) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	switch req.Topic {
+	case wsTopicContainers:
+		ws.containers = false
+	case wsTopicStats:
+		if req.ContainerID == "" {
+			ws.statsAll = false
+			ws.stats = make(map[string]bool)
+		} else {
+			delete(ws.stats, req.ContainerID)
+		}
+	case wsTopicLogs:
+		if cancel, exists := ws.logs[req.ContainerID]; exists {
+			cancel()
+			delete(ws.logs, req.ContainerID)
+		}
+	default:
+		return errors.New("unknown topic: " + req.Topic)
+	}
+	return nil
+}
+
+func (ws *wsSession) streamLogs(ctx context.Context, id string, tail int) {
+	err := ws.srv.service.StreamLogs(ctx, id, tail, func(line docker.LogLine) error {
+		ws.enqueue(wsMessage{Type: wsTopicLogs, ContainerID: id, Data: line}, true)
+		return nil
+	})
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if ctx.Err() != nil {
+		return // unsubscribed or connection closed
+	}
+	ws.logs[id]()
+	delete(ws.logs, id)
+
+	end := wsMessage{Type: wsTopicLogs, Event: "end", ContainerID: id}
+	if err != nil {
+		end.Error = err.Error()
+	}
+	ws.enqueue(end, false)
+}
+
+func (ws *wsSession) reply(req wsRequest, err error) {
+	reply := wsReply{Type: "reply", ID: req.ID, OK: err == nil}
+	if err != nil {
+		reply.Error = err.Error()
+	}
+	ws.enqueue(reply, false)
+}
+
+// enqueue queues a message for the writer without blocking. When the queue is full
+// droppable messages are discarded and anything else closes the connection.
+func (ws *wsSession) enqueue(v interface{}, droppable bool) {
+	msg, err := json.Marshal(v)
+	if err != nil {
+		logger.New().Warn("Error encoding websocket message: %v", err)
+		return
+	}
+
+	select {
+	case ws.send <- msg:
+	case <-ws.ctx.Done():
+	default:
+		if !droppable {
+			_ = ws.conn.CloseWithCode(wsCloseTryAgainLater, "slow consumer")
+			ws.cancel()
+		}
+	}
+}
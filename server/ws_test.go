@@ -0,0 +1,267 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yarlson/duh/docker"
+	"github.com/yarlson/duh/service"
+	"github.com/yarlson/duh/store"
+)
+
+// wsTestClient is a minimal WebSocket client for exercising handleWebSocket
+type wsTestClient struct {
+	t    *testing.T
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+func dialWebSocket(t *testing.T, serverURL string) *wsTestClient {
+	t.Helper()
+	client, resp := handshakeWebSocket(t, serverURL, "")
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected status %d, got %d", http.StatusSwitchingProtocols, resp.StatusCode)
+	}
+	return client
+}
+
+// handshakeWebSocket sends an opening handshake with an optional Origin header and
+// returns the client with the server's response
+func handshakeWebSocket(t *testing.T, serverURL, origin string) (*wsTestClient, *http.Response) {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(serverURL, "http://"))
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	key := make([]byte, 16)
+	_, _ = rand.Read(key)
+	encodedKey := base64.StdEncoding.EncodeToString(key)
+
+	req, _ := http.NewRequest("GET", serverURL+"/api/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", encodedKey)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	if err := req.Write(conn); err != nil {
+		t.Fatalf("Failed to write handshake: %v", err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatalf("Failed to read handshake: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	if resp.StatusCode == http.StatusSwitchingProtocols && resp.Header.Get("Sec-WebSocket-Accept") != websocketAccept(encodedKey) {
+		t.Fatalf("Unexpected Sec-WebSocket-Accept %q", resp.Header.Get("Sec-WebSocket-Accept"))
+	}
+
+	return &wsTestClient{t: t, conn: conn, br: br}, resp
+}
+
+func TestWebSocketRejectsCrossOrigin(t *testing.T) {
+	ts := newWebSocketTestServer(t, &DockerClientMock{}, store.NewStore(time.Minute))
+
+	_, resp := handshakeWebSocket(t, ts.URL, "http://evil.example.com")
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status %d for a cross-origin handshake, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+}
+
+func TestWebSocketAllowsSameOrigin(t *testing.T) {
+	ts := newWebSocketTestServer(t, &DockerClientMock{}, store.NewStore(time.Minute))
+
+	_, resp := handshakeWebSocket(t, ts.URL, ts.URL)
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("Expected status %d for a same-origin handshake, got %d", http.StatusSwitchingProtocols, resp.StatusCode)
+	}
+}
+
+func (c *wsTestClient) writeFrame(opcode byte, payload []byte) {
+	c.t.Helper()
+	frame := []byte{0x80 | opcode}
+	switch {
+	case len(payload) <= 125:
+		frame = append(frame, 0x80|byte(len(payload)))
+	default:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := c.conn.Write(frame); err != nil {
+		c.t.Fatalf("Failed to write frame: %v", err)
+	}
+}
+
+func (c *wsTestClient) readFrame() (byte, []byte) {
+	c.t.Helper()
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		c.t.Fatalf("Failed to read frame: %v", err)
+	}
+	length := int(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		_, _ = io.ReadFull(c.br, ext[:])
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		_, _ = io.ReadFull(c.br, ext[:])
+		length = int(binary.BigEndian.Uint64(ext[:]))
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		c.t.Fatalf("Failed to read payload: %v", err)
+	}
+	return header[0] & 0x0F, payload
+}
+
+func (c *wsTestClient) send(req wsRequest) {
+	c.t.Helper()
+	payload, _ := json.Marshal(req)
+	c.writeFrame(wsOpText, payload)
+}
+
+// next returns the next text message decoded into a generic map
+func (c *wsTestClient) next() map[string]interface{} {
+	c.t.Helper()
+	for {
+		opcode, payload := c.readFrame()
+		if opcode != wsOpText {
+			continue
+		}
+		var msg map[string]interface{}
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			c.t.Fatalf("Failed to decode message: %v", err)
+		}
+		return msg
+	}
+}
+
+// nextReply skips pushed messages until the reply to the given request ID
+func (c *wsTestClient) nextReply(id string) map[string]interface{} {
+	c.t.Helper()
+	for {
+		if msg := c.next(); msg["type"] == "reply" && msg["id"] == id {
+			return msg
+		}
+	}
+}
+
+func newWebSocketTestServer(t *testing.T, client *DockerClientMock, memoryStore *store.Store) *httptest.Server {
+	t.Helper()
+	srv := New(service.New(client, memoryStore), testFiles)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/ws", srv.handleWebSocket)
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestWebSocketContainersAndActions(t *testing.T) {
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(store.ContainerData{ID: "web", State: "exited"})
+
+	mockDocker := &DockerClientMock{
+		StartContainerFunc: func(ctx context.Context, id string) error {
+			return nil
+		},
+	}
+	ts := newWebSocketTestServer(t, mockDocker, memoryStore)
+
+	client := dialWebSocket(t, ts.URL)
+	defer func() { _ = client.conn.Close() }()
+
+	client.send(wsRequest{ID: "1", Type: "subscribe", Topic: wsTopicContainers})
+	if msg := client.next(); msg["type"] != wsTopicContainers || msg["event"] != "snapshot" {
+		t.Fatalf("Expected containers snapshot, got %v", msg)
+	}
+	if msg := client.next(); msg["type"] != "reply" || msg["id"] != "1" || msg["ok"] != true {
+		t.Fatalf("Expected ok reply to request 1, got %v", msg)
+	}
+
+	client.send(wsRequest{ID: "2", Type: "action", Action: "start", ContainerID: "web"})
+
+	// The action produces a container update and a reply, in either order
+	var gotUpdate, gotReply bool
+	for !gotUpdate || !gotReply {
+		msg := client.next()
+		switch msg["type"] {
+		case wsTopicContainers:
+			data := msg["data"].(map[string]interface{})
+			if data["state"] == store.StateStarting {
+				gotUpdate = true
+			}
+		case "reply":
+			if msg["id"] != "2" || msg["ok"] != true {
+				t.Fatalf("Expected ok reply to request 2, got %v", msg)
+			}
+			gotReply = true
+		}
+	}
+
+	client.send(wsRequest{ID: "3", Type: "subscribe", Topic: "bogus"})
+	if msg := client.nextReply("3"); msg["ok"] != false || msg["error"] == "" {
+		t.Errorf("Expected error reply for unknown topic, got %v", msg)
+	}
+
+	// Ping frames are answered with pong
+	client.writeFrame(wsOpPing, []byte("hi"))
+	if opcode, payload := client.readFrame(); opcode != wsOpPong || string(payload) != "hi" {
+		t.Errorf("Expected pong with payload, got opcode %d payload %q", opcode, payload)
+	}
+}
+
+func TestWebSocketLogs(t *testing.T) {
+	mockDocker := &DockerClientMock{
+		ContainerLogsFunc: func(ctx context.Context, id string, opts docker.LogOptions) (io.ReadCloser, error) {
+			if !opts.Follow || opts.Tail != 10 {
+				t.Errorf("Unexpected log options %+v", opts)
+			}
+			return io.NopCloser(strings.NewReader("line one\nline two\n")), nil
+		},
+	}
+	ts := newWebSocketTestServer(t, mockDocker, store.NewStore(time.Minute))
+
+	client := dialWebSocket(t, ts.URL)
+	defer func() { _ = client.conn.Close() }()
+
+	client.send(wsRequest{ID: "1", Type: "subscribe", Topic: wsTopicLogs, ContainerID: "web", Tail: 10})
+
+	var lines []string
+	for {
+		msg := client.next()
+		if msg["type"] != wsTopicLogs {
+			continue
+		}
+		if msg["event"] == "end" {
+			break
+		}
+		lines = append(lines, msg["data"].(map[string]interface{})["line"].(string))
+	}
+
+	if len(lines) != 2 || lines[0] != "line one" || lines[1] != "line two" {
+		t.Errorf("Unexpected log lines %v", lines)
+	}
+}
@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yarlson/duh/docker"
+	"github.com/yarlson/duh/logger"
+	"github.com/yarlson/duh/store"
+)
+
+// Labels that opt a container in to automatic healing and tune it
+const (
+	AutohealLabel            = "duh.autoheal"              // "true" to enable
+	AutohealFailuresLabel    = "duh.autoheal.failures"     // Consecutive failed healthchecks before a restart
+	AutohealMaxRestartsLabel = "duh.autoheal.max-restarts" // Restarts allowed per AutohealWindow
+)
+
+const (
+	DefaultAutohealFailures    = 3
+	DefaultAutohealMaxRestarts = 5
+	// AutohealWindow is the period over which restarts are counted for the circuit breaker
+	AutohealWindow = 30 * time.Minute
+
+	autohealInitialBackoff = 10 * time.Second
+	autohealMaxBackoff     = 5 * time.Minute
+	autohealLogSize        = 200
+)
+
+// Autoheal audit actions
+const (
+	HealRestart     = "restart"      // an unhealthy container was restarted
+	HealStart       = "start"        // an exited container was started
+	HealCircuitOpen = "circuit_open" // too many restarts, healing paused for the container
+)
+
+// HealEvent records an automatic action taken on a container
+type HealEvent struct {
+	ContainerID string    `json:"container_id"`
+	Name        string    `json:"name"`
+	Action      string    `json:"action"`
+	Reason      string    `json:"reason"`
+	Error       string    `json:"error,omitempty"`
+	At          time.Time `json:"at"`
+}
+
+// healState is the healing history of one container
+type healState struct {
+	restarts     []time.Time // attempts within AutohealWindow
+	backoff      time.Duration
+	nextAttempt  time.Time
+	healthySince time.Time // zero while the container needs healing
+	healing      bool
+	circuitOpen  bool
+}
+
+type autohealer struct {
+	mu     sync.Mutex
+	states map[string]*healState
+	log    []HealEvent
+	wg     sync.WaitGroup
+}
+
+// WithAutoheal enables automatic healing of containers labelled duh.autoheal=true.
+// After every sync, containers that exited non-zero or failed their healthcheck
+// duh.autoheal.failures times in a row are started or restarted, backing off
+// exponentially between attempts. A container restarted duh.autoheal.max-restarts
+// times within AutohealWindow is left alone until the window passes.
+func WithAutoheal() Option {
+	return func(s *ContainerService) {
+		s.autoheal = &autohealer{states: make(map[string]*healState)}
+	}
+}
+
+// AutohealLog returns the automatic actions taken, oldest first
+func (s *ContainerService) AutohealLog() []HealEvent {
+	if s.autoheal == nil {
+		return []HealEvent{}
+	}
+	s.autoheal.mu.Lock()
+	defer s.autoheal.mu.Unlock()
+	return append([]HealEvent{}, s.autoheal.log...)
+}
+
+// runAutoheal checks every opted-in container and heals those that need it in the
+// background
+func (s *ContainerService) runAutoheal(ctx context.Context, now time.Time) {
+	h := s.autoheal
+	if h == nil {
+		return
+	}
+
+	for _, c := range s.store.List() {
+		if !labelBool(c.Labels, AutohealLabel) {
+			continue
+		}
+		reason := s.healReason(c)
+
+		h.mu.Lock()
+		state := h.states[c.ID]
+		if state == nil {
+			state = &healState{backoff: autohealInitialBackoff}
+			h.states[c.ID] = state
+		}
+		action, ok := h.plan(c, state, reason, now)
+		h.mu.Unlock()
+
+		if !ok {
+			continue
+		}
+		h.wg.Add(1)
+		go func(c store.ContainerData) {
+			defer h.wg.Done()
+			s.heal(ctx, c, action, reason)
+		}(c)
+	}
+
+	// Forget containers that no longer exist
+	h.mu.Lock()
+	for id := range h.states {
+		if _, exists := s.store.Get(id); !exists {
+			delete(h.states, id)
+		}
+	}
+	h.mu.Unlock()
+}
+
+// plan decides whether to act on a container now. It is called with h.mu held.
+func (h *autohealer) plan(c store.ContainerData, state *healState, reason string, now time.Time) (string, bool) {
+	if reason == "" {
+		// Start over with a short backoff once the container has stayed healthy
+		// for a while, not merely between two crashes
+		if state.healthySince.IsZero() {
+			state.healthySince = now
+		}
+		if now.Sub(state.healthySince) >= autohealMaxBackoff {
+			state.backoff = autohealInitialBackoff
+		}
+		return "", false
+	}
+	state.healthySince = time.Time{}
+	if state.healing || now.Before(state.nextAttempt) {
+		return "", false
+	}
+
+	// Drop attempts that fell out of the window
+	recent := state.restarts[:0]
+	for _, at := range state.restarts {
+		if now.Sub(at) < AutohealWindow {
+			recent = append(recent, at)
+		}
+	}
+	state.restarts = recent
+
+	if len(state.restarts) >= labelInt(c.Labels, AutohealMaxRestartsLabel, DefaultAutohealMaxRestarts) {
+		if !state.circuitOpen {
+			state.circuitOpen = true
+			h.record(HealEvent{
+				ContainerID: c.ID,
+				Name:        containerName(c),
+				Action:      HealCircuitOpen,
+				Reason:      fmt.Sprintf("%s; restarted %d times in %s", reason, len(state.restarts), AutohealWindow),
+				At:          now,
+			})
+		}
+		return "", false
+	}
+	state.circuitOpen = false
+
+	state.healing = true
+	state.restarts = append(state.restarts, now)
+	state.nextAttempt = now.Add(state.backoff)
+	state.backoff *= 2
+	if state.backoff > autohealMaxBackoff {
+		state.backoff = autohealMaxBackoff
+	}
+
+	if c.State == "exited" {
+		return HealStart, true
+	}
+	return HealRestart, true
+}
+
+// healReason explains why a container needs healing, or returns an empty string.
+// Like Docker's restart policy, containers stopped on purpose are left alone, whether
+// duh or another client such as docker stop or docker compose stop stopped them.
+func (s *ContainerService) healReason(c store.ContainerData) string {
+	switch {
+	case c.State == "exited":
+		if c.ExitCode == nil || *c.ExitCode == 0 {
+			return ""
+		}
+		if c.LastAction != nil && c.LastAction.Action == "stop" {
+			return "" // stopped through duh
+		}
+		if s.stopRequested(c.ID) {
+			return "" // sent a kill or stop by another client
+		}
+		return fmt.Sprintf("exited with code %d", *c.ExitCode)
+	case c.State == "running" && c.Health != nil && c.Health.Status == store.HealthUnhealthy:
+		// The failing streak is kept up to date by SyncHealth
+		threshold := labelInt(c.Labels, AutohealFailuresLabel, DefaultAutohealFailures)
+		if c.Health.FailingStreak < threshold {
+			return ""
+		}
+		return fmt.Sprintf("unhealthy for %d consecutive checks", c.Health.FailingStreak)
+	}
+	return ""
+}
+
+func (s *ContainerService) heal(ctx context.Context, c store.ContainerData, action, reason string) {
+	var err error
+	if action == HealRestart {
+		err = s.StopContainer(ctx, c.ID, docker.StopOptions{})
+	}
+	if err == nil {
+		err = s.StartContainer(ctx, c.ID)
+	}
+
+	event := HealEvent{
+		ContainerID: c.ID,
+		Name:        containerName(c),
+		Action:      action,
+		Reason:      reason,
+		At:          time.Now(),
+	}
+	if err != nil {
+		event.Error = err.Error()
+		logger.New().Warn("Autoheal %s of %s failed: %v", action, event.Name, err)
+	} else {
+		logger.New().Info("Autoheal %s of %s: %s", action, event.Name, reason)
+	}
+
+	h := s.autoheal
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.record(event)
+	if state := h.states[c.ID]; state != nil {
+		state.healing = false
+	}
+}
+
+// record appends to the audit log, keeping the most recent entries. It is called
+// with h.mu held.
+func (h *autohealer) record(event HealEvent) {
+	h.log = append(h.log, event)
+	if len(h.log) > autohealLogSize {
+		h.log = append([]HealEvent(nil), h.log[len(h.log)-autohealLogSize:]...)
+	}
+}
+
+func labelBool(labels map[string]string, key string) bool {
+	value, _ := strconv.ParseBool(strings.TrimSpace(labels[key]))
+	return value
+}
+
+func labelInt(labels map[string]string, key string, fallback int) int {
+	value, err := strconv.Atoi(strings.TrimSpace(labels[key]))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yarlson/duh/docker"
+	"github.com/yarlson/duh/store"
+)
+
+func TestServiceAutohealExited(t *testing.T) {
+	mockDocker := &DockerClientMock{
+		StartContainerFunc: func(ctx context.Context, id string) error {
+			return nil
+		},
+	}
+
+	memoryStore := store.NewStore(time.Minute)
+	service := New(mockDocker, memoryStore, WithAutoheal())
+
+	crashed := func(id string, labels map[string]string) store.ContainerData {
+		code := 1
+		return store.ContainerData{ID: id, Names: []string{"/" + id}, State: "exited", ExitCode: &code, Labels: labels}
+	}
+	optIn := map[string]string{AutohealLabel: "true", AutohealMaxRestartsLabel: "2"}
+	memoryStore.Update(crashed("worker", optIn))
+	memoryStore.Update(crashed("ignored", nil))
+	stopped := crashed("stopped", optIn)
+	stopped.LastAction = &store.ActionResult{Action: "stop", Outcome: store.OutcomeSucceeded}
+	memoryStore.Update(stopped)
+	// Stopped outside duh, e.g. with docker compose stop
+	memoryStore.Update(crashed("external", optIn))
+	service.handleDockerEvent(context.Background(), containerEvent("external", "kill"))
+	service.handleDockerEvent(context.Background(), containerEvent("external", "stop"))
+
+	now := time.Now()
+	service.runAutoheal(context.Background(), now)
+	service.Close()
+
+	calls := mockDocker.StartContainerCalls()
+	if len(calls) != 1 || calls[0].ID != "worker" {
+		t.Fatalf("Expected only worker to be started, got %+v", calls)
+	}
+
+	// Crashes again right away: wait for the backoff
+	memoryStore.Update(crashed("worker", optIn))
+	service.runAutoheal(context.Background(), now.Add(time.Second))
+	service.Close()
+	if len(mockDocker.StartContainerCalls()) != 1 {
+		t.Fatal("Expected no start during backoff")
+	}
+
+	service.runAutoheal(context.Background(), now.Add(autohealInitialBackoff))
+	service.Close()
+	if len(mockDocker.StartContainerCalls()) != 2 {
+		t.Fatal("Expected a start after the backoff")
+	}
+
+	// Two restarts in the window trip the circuit breaker, reported once
+	memoryStore.Update(crashed("worker", optIn))
+	service.runAutoheal(context.Background(), now.Add(time.Hour/4))
+	service.runAutoheal(context.Background(), now.Add(time.Hour/3))
+	service.Close()
+	if len(mockDocker.StartContainerCalls()) != 2 {
+		t.Fatal("Expected no start with the circuit open")
+	}
+
+	log := service.AutohealLog()
+	var actions []string
+	for _, event := range log {
+		actions = append(actions, event.Action)
+	}
+	if strings.Join(actions, ",") != "start,start,circuit_open" {
+		t.Errorf("Unexpected audit trail %v", actions)
+	}
+	if log[0].ContainerID != "worker" || log[0].Reason != "exited with code 1" {
+		t.Errorf("Unexpected audit entry %+v", log[0])
+	}
+
+	// Once the window has passed the container is healed again
+	service.runAutoheal(context.Background(), now.Add(AutohealWindow+time.Hour))
+	service.Close()
+	if len(mockDocker.StartContainerCalls()) != 3 {
+		t.Error("Expected a start after the window passed")
+	}
+}
+
+func TestServiceAutohealUnhealthy(t *testing.T) {
+	streak := 2
+	mockDocker := &DockerClientMock{
+		InspectContainerFunc: func(ctx context.Context, id string) (*docker.ContainerInspect, error) {
+			inspect := &docker.ContainerInspect{ID: id}
+			inspect.State.Health = &docker.Health{Status: "unhealthy", FailingStreak: streak}
+			return inspect, nil
+		},
+		StopContainerFunc: func(ctx context.Context, id string, opts docker.StopOptions) error {
+			return nil
+		},
+		StartContainerFunc: func(ctx context.Context, id string) error {
+			return nil
+		},
+	}
+
+	api := docker.Container{
+		ID:     "api",
+		State:  "running",
+		Status: "Up 5 minutes (unhealthy)",
+		Labels: map[string]string{AutohealLabel: "true"},
+	}
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(containerData(api))
+	service := New(mockDocker, memoryStore, WithAutoheal())
+
+	service.SyncHealth(context.Background(), []docker.Container{api})
+	service.runAutoheal(context.Background(), time.Now())
+	service.Close()
+	if len(mockDocker.StopContainerCalls()) != 0 {
+		t.Fatal("Expected no restart below the failure threshold")
+	}
+
+	streak = DefaultAutohealFailures
+	service.SyncHealth(context.Background(), []docker.Container{api})
+	service.runAutoheal(context.Background(), time.Now())
+	service.Close()
+	if len(mockDocker.StopContainerCalls()) != 1 || len(mockDocker.StartContainerCalls()) != 1 {
+		t.Fatalf("Expected a restart, got %d stops and %d starts",
+			len(mockDocker.StopContainerCalls()), len(mockDocker.StartContainerCalls()))
+	}
+
+	log := service.AutohealLog()
+	if len(log) != 1 || log[0].Action != HealRestart || log[0].Reason != "unhealthy for 3 consecutive checks" {
+		t.Errorf("Unexpected audit trail %+v", log)
+	}
+}
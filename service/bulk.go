@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/yarlson/duh/docker"
+	"github.com/yarlson/duh/store"
+)
+
+const (
+	// DefaultBulkConcurrency is the number of containers acted on at once by BulkAction
+	DefaultBulkConcurrency = 4
+	// MaxBulkConcurrency caps the concurrency a caller may request
+	MaxBulkConcurrency = 16
+)
+
+// Bulk result statuses
+const (
+	BulkSucceeded = "succeeded"
+	BulkFailed    = "failed"
+	BulkSkipped   = "skipped"
+)
+
+var (
+	// ErrInvalidAction is returned for actions other than start and stop
+	ErrInvalidAction = errors.New("invalid action")
+	// ErrNoTargets is returned when a bulk request has neither IDs nor a selector
+	ErrNoTargets = errors.New("ids or selector required")
+)
+
+// BulkRequest describes an action applied to many containers. Targets are the listed
+// IDs plus every container matching the selector. Timeout (seconds) and Signal apply
+// to stop actions.
+type BulkRequest struct {
+	Action      string    `json:"action"`
+	IDs         []string  `json:"ids,omitempty"`
+	Selector    *Selector `json:"selector,omitempty"`
+	Concurrency int       `json:"concurrency,omitempty"`
+	Timeout     *int      `json:"timeout,omitempty"`
+	Signal      string    `json:"signal,omitempty"`
+}
+
+// BulkResult is the outcome of a bulk action for a single container
+type BulkResult struct {
+	ID     string `json:"id"`
+	Name   string `json:"name,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BulkAction runs an action on every targeted container with bounded concurrency and
+// returns one result per container in target order. Containers already in the desired
+// state are skipped; unknown IDs and failed actions are reported as failures.
+func (s *ContainerService) BulkAction(ctx context.Context, req BulkRequest) ([]BulkResult, error) {
+	if req.Action != "start" && req.Action != "stop" {
+		return nil, ErrInvalidAction
+	}
+	if len(req.IDs) == 0 && (req.Selector == nil || req.Selector.Empty()) {
+		return nil, ErrNoTargets
+	}
+
+	concurrency := req.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBulkConcurrency
+	}
+	if concurrency > MaxBulkConcurrency {
+		concurrency = MaxBulkConcurrency
+	}
+
+	targets := s.bulkTargets(req)
+	results := make([]BulkResult, len(targets))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, target := range targets {
+		results[i] = BulkResult{ID: target.id, Name: containerName(target.container)}
+
+		if !target.found {
+			results[i].Status = BulkFailed
+			results[i].Error = "container not found"
+			continue
+		}
+		if reason := skipReason(req.Action, target.container); reason != "" {
+			results[i].Status = BulkSkipped
+			results[i].Error = reason
+			continue
+		}
+
+		wg.Add(1)
+		go func(result *BulkResult) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				result.Status = BulkFailed
+				result.Error = ctx.Err().Error()
+				return
+			}
+
+			if err := s.runAction(ctx, result.ID, req); err != nil {
+				result.Status = BulkFailed
+				result.Error = err.Error()
+				return
+			}
+			result.Status = BulkSucceeded
+		}(&results[i])
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+type bulkTarget struct {
+	id        string
+	container store.ContainerData
+	found     bool
+}
+
+// bulkTargets resolves explicit IDs and selector matches, without duplicates
+func (s *ContainerService) bulkTargets(req BulkRequest) []bulkTarget {
+	seen := make(map[string]bool)
+	var targets []bulkTarget
+
+	for _, id := range req.IDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		container, found := s.store.Get(id)
+		targets = append(targets, bulkTarget{id: id, container: container, found: found})
+	}
+
+	if req.Selector != nil && !req.Selector.Empty() {
+		for _, container := range s.List() {
+			if seen[container.ID] || !req.Selector.Match(container) {
+				continue
+			}
+			seen[container.ID] = true
+			targets = append(targets, bulkTarget{id: container.ID, container: container, found: true})
+		}
+	}
+
+	return targets
+}
+
+// skipReason explains why an action would have no effect on a container
+func skipReason(action string, c store.ContainerData) string {
+	switch action {
+	case "start":
+		if c.State == "running" || c.State == store.StateStarting {
+			return "already running"
+		}
+	case "stop":
+		switch c.State {
+		case "exited", "created", "dead", store.StateStopping:
+			return "not running"
+		}
+	}
+	return ""
+}
+
+// runAction dispatches the request's action for a single container
+func (s *ContainerService) runAction(ctx context.Context, id string, req BulkRequest) error {
+	switch req.Action {
+	case "start":
+		return s.StartContainer(ctx, id)
+	case "stop":
+		return s.StopContainer(ctx, id, docker.StopOptions{Timeout: req.Timeout, Signal: req.Signal})
+	default:
+		return ErrInvalidAction
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yarlson/duh/docker"
+	"github.com/yarlson/duh/store"
+)
+
+func TestSelectorMatch(t *testing.T) {
+	container := store.ContainerData{
+		ID:      "1",
+		Names:   []string{"/payments-api-1"},
+		Image:   "redis:7",
+		State:   "running",
+		Labels:  map[string]string{"team": "payments"},
+		Compose: &store.ComposeInfo{Project: "payments", Service: "api"},
+	}
+
+	testCases := []struct {
+		selector Selector
+		want     bool
+	}{
+		{Selector{Label: "team=payments"}, true},
+		{Selector{Label: "team"}, true},
+		{Selector{Label: "team=search"}, false},
+		{Selector{Image: "redis*"}, true},
+		{Selector{Image: "postgres*"}, false},
+		{Selector{Name: "payments-*"}, true},
+		{Selector{Name: "search-*"}, false},
+		{Selector{State: "running", Label: "team=payments"}, true},
+		{Selector{State: "exited", Label: "team=payments"}, false},
+		{Selector{Project: "payments"}, true},
+		{Selector{Project: "search"}, false},
+	}
+
+	for _, tc := range testCases {
+		if got := tc.selector.Match(container); got != tc.want {
+			t.Errorf("%+v.Match() = %v, want %v", tc.selector, got, tc.want)
+		}
+	}
+}
+
+func TestServiceBulkAction(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	mockDocker := &DockerClientMock{
+		StopContainerFunc: func(ctx context.Context, id string, opts docker.StopOptions) error {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				max := maxInFlight.Load()
+				if n <= max || maxInFlight.CompareAndSwap(max, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			if id == "broken" {
+				return errors.New("permission denied")
+			}
+			return nil
+		},
+		ListContainersFunc: func(ctx context.Context, all bool) ([]docker.Container, error) {
+			return nil, nil
+		},
+	}
+
+	memoryStore := store.NewStore(time.Minute)
+	for _, id := range []string{"a", "b", "c", "d", "broken"} {
+		memoryStore.Update(store.ContainerData{
+			ID:     id,
+			State:  "running",
+			Labels: map[string]string{"team": "payments"},
+		})
+	}
+	memoryStore.Update(store.ContainerData{ID: "idle", State: "exited", Labels: map[string]string{"team": "payments"}})
+	memoryStore.Update(store.ContainerData{ID: "other", State: "running"})
+
+	service := New(mockDocker, memoryStore)
+
+	results, err := service.BulkAction(context.Background(), BulkRequest{
+		Action:      "stop",
+		IDs:         []string{"missing"},
+		Selector:    &Selector{Label: "team=payments"},
+		Concurrency: 2,
+	})
+	if err != nil {
+		t.Fatalf("BulkAction failed: %v", err)
+	}
+
+	statuses := make(map[string]string)
+	for _, r := range results {
+		statuses[r.ID] = r.Status
+	}
+
+	want := map[string]string{
+		"missing": BulkFailed,
+		"a":       BulkSucceeded,
+		"b":       BulkSucceeded,
+		"c":       BulkSucceeded,
+		"d":       BulkSucceeded,
+		"broken":  BulkFailed,
+		"idle":    BulkSkipped,
+	}
+	if len(statuses) != len(want) {
+		t.Errorf("Got results for %v, want %v", statuses, want)
+	}
+	for id, status := range want {
+		if statuses[id] != status {
+			t.Errorf("Container %s: got status %q, want %q", id, statuses[id], status)
+		}
+	}
+
+	if got := maxInFlight.Load(); got > 2 {
+		t.Errorf("Expected at most 2 concurrent stops, got %d", got)
+	}
+
+	if _, err := service.BulkAction(context.Background(), BulkRequest{Action: "restart", IDs: []string{"a"}}); !errors.Is(err, ErrInvalidAction) {
+		t.Errorf("Expected ErrInvalidAction, got %v", err)
+	}
+	if _, err := service.BulkAction(context.Background(), BulkRequest{Action: "stop"}); !errors.Is(err, ErrNoTargets) {
+		t.Errorf("Expected ErrNoTargets, got %v", err)
+	}
+}
@@ -2,8 +2,12 @@ package service
 
 import (
 	"context"
+	"io"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/yarlson/duh/docker"
 	"github.com/yarlson/duh/store"
@@ -14,9 +18,13 @@ import (
 // DockerClient defines the interface for Docker operations
 type DockerClient interface {
 	ListContainers(ctx context.Context, all bool) ([]docker.Container, error)
+	GetContainer(ctx context.Context, id string) (*docker.Container, error)
 	GetContainerStats(ctx context.Context, id string) (*docker.ContainerStats, error)
 	StartContainer(ctx context.Context, id string) error
-	StopContainer(ctx context.Context, id string) error
+	StopContainer(ctx context.Context, id string, opts docker.StopOptions) error
+	ContainerLogs(ctx context.Context, id string, opts docker.LogOptions) (io.ReadCloser, error)
+	InspectContainer(ctx context.Context, id string) (*docker.ContainerInspect, error)
+	Events(ctx context.Context, fn func(docker.Event) error) error
 }
 
 // Store defines the interface for container data storage
@@ -26,19 +34,94 @@ type Store interface {
 	List() []store.ContainerData
 	Get(id string) (store.ContainerData, bool)
 	RemoveStaleData()
+	Subscribe(ctx context.Context, buffer int) <-chan store.Event
+	EventsSince(seq uint64) ([]store.Event, bool)
+	Snapshot() ([]store.ContainerData, uint64)
+	Since(version uint64) ([]store.ContainerData, []string, uint64, bool)
+	Remove(id string) bool
+	Tombstones() []store.ContainerData
+	ExpireTransitions(now time.Time) []store.ContainerData
+	RecordAction(id string, result store.ActionResult) bool
+	SetDiagnostics(id string, diagnostics *store.Diagnostics) bool
+	SetHealth(id string, health *store.Health) bool
 }
 
+// DefaultTransitionTimeout is how long a container may stay starting or stopping
+// before it is reported in the error state
+const DefaultTransitionTimeout = time.Minute
+
 // ContainerService coordinates between Docker client and data store
 type ContainerService struct {
-	client DockerClient
-	store  Store
+	client            DockerClient
+	store             Store
+	transitionTimeout time.Duration
+	actionRefresh     bool
+
+	refreshMu sync.Mutex
+	refreshes map[string]*refreshCall
+
+	opsMu sync.Mutex
+	ops   map[string]*operation
+
+	metrics metrics
+
+	syncHooks []SyncHook
+
+	diagMu    sync.Mutex
+	oomKilled map[string]bool // containers with an oom event awaiting their die event
+	stopped   map[string]bool // containers sent a kill or stop since they last started
+
+	autoheal *autohealer  // nil unless WithAutoheal is set
+	idle     *idleStopper // nil unless WithIdleStop is set
+	critical Filter       // critical containers besides those labelled duh.critical
+}
+
+// SyncHook is called with the stored containers after every successful Sync
+type SyncHook func(ctx context.Context, containers []store.ContainerData)
+
+// Option configures a ContainerService
+type Option func(*ContainerService)
+
+// WithTransitionTimeout sets how long start and stop transitions may take
+func WithTransitionTimeout(timeout time.Duration) Option {
+	return func(s *ContainerService) {
+		s.transitionTimeout = timeout
+	}
+}
+
+// WithSyncHook registers a hook that runs after every successful Sync, for example to
+// evaluate alert rules against fresh data
+func WithSyncHook(hook SyncHook) Option {
+	return func(s *ContainerService) {
+		s.syncHooks = append(s.syncHooks, hook)
+	}
 }
 
 // New creates a new container service
-func New(client DockerClient, store Store) *ContainerService {
-	return &ContainerService{
-		client: client,
-		store:  store,
+func New(client DockerClient, store Store, opts ...Option) *ContainerService {
+	s := &ContainerService{
+		client:            client,
+		store:             store,
+		transitionTimeout: DefaultTransitionTimeout,
+		refreshes:         make(map[string]*refreshCall),
+		ops:               make(map[string]*operation),
+		oomKilled:         make(map[string]bool),
+		stopped:           make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Close waits for background autoheal and idle stop actions to finish. Cancel the
+// context passed to Sync first to cut them short.
+func (s *ContainerService) Close() {
+	if s.autoheal != nil {
+		s.autoheal.wg.Wait()
+	}
+	if s.idle != nil {
+		s.idle.wg.Wait()
 	}
 }
 
@@ -48,6 +131,7 @@ func (s *ContainerService) SyncContainers(ctx context.Context) ([]docker.Contain
 	// Get all containers from Docker.
 	containers, err := s.client.ListContainers(ctx, true)
 	if err != nil {
+		s.metrics.dockerError(OpList, err)
 		return nil, err
 	}
 
@@ -57,35 +141,22 @@ func (s *ContainerService) SyncContainers(ctx context.Context) ([]docker.Contain
 		containerMap[c.ID] = c
 	}
 
-	// First, check all stored containers for state transitions.
+	// First, check all stored containers for removals and state transitions.
 	for _, stored := range s.store.List() {
 		dockerC, exists := containerMap[stored.ID]
+		if !exists {
+			// The container is gone from Docker, keep a tombstone instead
+			s.store.Remove(stored.ID)
+			continue
+		}
 		switch stored.State {
 		case store.StateStarting:
-			if !exists || dockerC.State == "running" {
-				if exists {
-					s.store.Update(store.ContainerData{
-						ID:      dockerC.ID,
-						Names:   dockerC.Names,
-						Image:   dockerC.Image,
-						State:   dockerC.State,
-						Status:  dockerC.Status,
-						Created: dockerC.Created,
-					})
-				}
+			if dockerC.State == "running" {
+				s.store.Update(containerData(dockerC))
 			}
 		case store.StateStopping:
-			if !exists || dockerC.State == "exited" {
-				if exists {
-					s.store.Update(store.ContainerData{
-						ID:      dockerC.ID,
-						Names:   dockerC.Names,
-						Image:   dockerC.Image,
-						State:   dockerC.State,
-						Status:  dockerC.Status,
-						Created: dockerC.Created,
-					})
-				}
+			if dockerC.State == "exited" {
+				s.store.Update(containerData(dockerC))
 			}
 		}
 	}
@@ -98,19 +169,62 @@ func (s *ContainerService) SyncContainers(ctx context.Context) ([]docker.Contain
 			}
 		}
 
-		data := store.ContainerData{
-			ID:      c.ID,
-			Names:   c.Names,
-			Image:   c.Image,
-			State:   c.State,
-			Status:  c.Status,
-			Created: c.Created,
-		}
-		s.store.Update(data)
+		s.store.Update(containerData(c))
 	}
+
+	// Finally, report transitions that did not resolve in time. Containers in the
+	// error state are refreshed from Docker, clearing the error, on the next sync.
+	s.store.ExpireTransitions(time.Now())
+	s.rediagnose(time.Now())
+
 	return containers, nil
 }
 
+// containerData converts a Docker container into its store representation
+func containerData(c docker.Container) store.ContainerData {
+	return store.ContainerData{
+		ID:       c.ID,
+		Names:    c.Names,
+		Image:    c.Image,
+		State:    c.State,
+		Status:   c.Status,
+		Created:  c.Created,
+		Labels:   c.Labels,
+		Ports:    portData(c.Ports),
+		Compose:  composeInfo(c.Labels),
+		ExitCode: parseExitCode(c.Status),
+		Health:   parseHealth(c.Status),
+	}
+}
+
+// portData converts Docker's port list into its store representation
+func portData(ports []docker.Port) []store.Port {
+	if len(ports) == 0 {
+		return nil
+	}
+	result := make([]store.Port, len(ports))
+	for i, p := range ports {
+		result[i] = store.Port{IP: p.IP, PrivatePort: p.PrivatePort, PublicPort: p.PublicPort, Type: p.Type}
+	}
+	return result
+}
+
+// parseExitCode extracts the exit code from a Docker status such as "Exited (137) 5 minutes ago"
+func parseExitCode(status string) *int {
+	if !strings.HasPrefix(status, "Exited (") {
+		return nil
+	}
+	end := strings.IndexByte(status, ')')
+	if end < 0 {
+		return nil
+	}
+	code, err := strconv.Atoi(status[len("Exited ("):end])
+	if err != nil {
+		return nil
+	}
+	return &code
+}
+
 // SyncStats updates statistics for running containers.
 // It accepts the container list (typically returned from SyncContainers) so that these operations are decoupled.
 func (s *ContainerService) SyncStats(ctx context.Context, containers []docker.Container) {
@@ -133,6 +247,7 @@ func (s *ContainerService) SyncStats(ctx context.Context, containers []docker.Co
 			defer wg.Done()
 			stats, err := s.client.GetContainerStats(ctx, c.ID)
 			if err != nil {
+				s.metrics.dockerError(OpStats, err)
 				return // Skip stats on error
 			}
 
@@ -172,52 +287,139 @@ func (s *ContainerService) SyncStats(ctx context.Context, containers []docker.Co
 			storeStats.CPU.Cores = stats.CPUStats.OnlineCPUs
 			storeStats.CPU.SystemMS = stats.CPUStats.SystemCPUUsage / 1_000_000 // Convert to milliseconds
 
+			s.networkStats(c.ID, stats, storeStats)
+			for _, entry := range stats.BlkioStats.IOServiceBytesRecursive {
+				switch strings.ToLower(entry.Op) {
+				case "read":
+					storeStats.BlockIO.ReadBytes += entry.Value
+				case "write":
+					storeStats.BlockIO.WriteBytes += entry.Value
+				}
+			}
+
 			s.store.UpdateStats(c.ID, storeStats)
 		}(c)
 	}
 	wg.Wait()
 }
 
+// networkStats sums traffic over all interfaces and derives throughput from the
+// previous sample of the container
+func (s *ContainerService) networkStats(id string, stats *docker.ContainerStats, storeStats *store.Stats) {
+	for _, network := range stats.Networks {
+		storeStats.Network.RxBytes += network.RxBytes
+		storeStats.Network.TxBytes += network.TxBytes
+	}
+	storeStats.Sampled = stats.Read
+	if storeStats.Sampled.IsZero() {
+		storeStats.Sampled = time.Now()
+	}
+
+	stored, exists := s.store.Get(id)
+	if !exists || stored.Stats == nil || stored.Stats.Sampled.IsZero() {
+		return
+	}
+	prev := stored.Stats
+	elapsed := storeStats.Sampled.Sub(prev.Sampled).Seconds()
+	// Counters reset when a container restarts; skip the rate for that sample
+	if elapsed <= 0 || storeStats.Network.RxBytes < prev.Network.RxBytes || storeStats.Network.TxBytes < prev.Network.TxBytes {
+		return
+	}
+	storeStats.Network.RxRate = float64(storeStats.Network.RxBytes-prev.Network.RxBytes) / elapsed
+	storeStats.Network.TxRate = float64(storeStats.Network.TxBytes-prev.Network.TxBytes) / elapsed
+}
+
 // Sync is updated to first sync the container list and then the statistics.
 func (s *ContainerService) Sync(ctx context.Context) error {
+	start := time.Now()
 	containers, err := s.SyncContainers(ctx)
+	if err == nil {
+		s.SyncStats(ctx, containers)
+		s.SyncHealth(ctx, containers)
+		s.store.RemoveStaleData()
+	}
+	s.metrics.observeSync(time.Since(start), err)
 	if err != nil {
 		return err
 	}
-	s.SyncStats(ctx, containers)
-	s.store.RemoveStaleData()
+
+	now := time.Now()
+	s.runAutoheal(ctx, now)
+	s.runIdleStop(ctx, now)
+	if len(s.syncHooks) > 0 {
+		stored := s.store.List()
+		for _, hook := range s.syncHooks {
+			hook(ctx, stored)
+		}
+	}
 	return nil
 }
 
-// StartContainer starts a container and waits for it to be running
+// StartContainer starts a container and waits for it to be running. It fails with a
+// ConflictError while another action is in progress on the container.
 func (s *ContainerService) StartContainer(ctx context.Context, id string) error {
-	// Get existing container data first
-	existing, exists := s.store.Get(id)
-	if !exists {
-		// If container doesn't exist in store, try to get it from Docker
-		containers, err := s.client.ListContainers(ctx, true)
-		if err == nil {
-			for _, c := range containers {
-				if c.ID == id {
-					existing = store.ContainerData{
-						ID:      c.ID,
-						Names:   c.Names,
-						Image:   c.Image,
-						Created: c.Created,
-					}
-					break
-				}
-			}
+	done, err := s.beginOperation(ctx, id, "start")
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	return s.startContainer(ctx, id)
+}
+
+// actionTarget returns the stored container, or looks it up in Docker when the store
+// does not know it. Unknown containers are reported as docker.ErrNotFound without
+// touching the store.
+func (s *ContainerService) actionTarget(ctx context.Context, id string) (store.ContainerData, error) {
+	if existing, exists := s.store.Get(id); exists {
+		return existing, nil
+	}
+
+	containers, err := s.client.ListContainers(ctx, true)
+	if err != nil {
+		s.metrics.dockerError(OpList, err)
+		return store.ContainerData{}, err
+	}
+	for _, c := range containers {
+		if c.ID == id {
+			return store.ContainerData{
+				ID:      c.ID,
+				Names:   c.Names,
+				Image:   c.Image,
+				Created: c.Created,
+				Labels:  c.Labels,
+				Compose: composeInfo(c.Labels),
+			}, nil
 		}
 	}
+	return store.ContainerData{}, docker.ErrNotFound
+}
+
+func (s *ContainerService) startContainer(ctx context.Context, id string) error {
+	existing, err := s.actionTarget(ctx, id)
+	if err != nil {
+		return err
+	}
 
 	// Set intermediate state while preserving other fields
 	existing.State = store.StateStarting
 	existing.Status = "Starting" // Add status to show in UI
+	existing.Error = ""
+	deadline := time.Now().Add(s.transitionTimeout)
+	existing.Deadline = &deadline
 	s.store.Update(existing)
 
-	err := s.client.StartContainer(ctx, id)
+	// Bound the Docker call so a hanging daemon cannot hold the request forever
+	callCtx, cancel := context.WithTimeout(ctx, s.transitionTimeout)
+	defer cancel()
+
+	err = s.client.StartContainer(callCtx, id)
+	s.metrics.dockerError(OpStart, err)
 	if err != nil {
+		// Keep the error on the record in case the transition never resolves
+		existing.Error = err.Error()
+		s.store.Update(existing)
+
 		// On error, try to get current state from Docker
 		containers, listErr := s.client.ListContainers(ctx, true)
 		if listErr == nil {
@@ -225,48 +427,65 @@ func (s *ContainerService) StartContainer(ctx context.Context, id string) error
 				if c.ID == id {
 					existing.State = c.State
 					existing.Status = c.Status
+					existing.Deadline = nil
 					s.store.Update(existing)
 					break
 				}
 			}
 		}
+		s.recordAction(id, "start", err)
 		return err
 	}
 
-	// Let the next Sync update pick up the final state
+	// Refresh the container now, or let the next Sync update pick up the final state
+	s.recordAction(id, "start", nil)
+	s.refreshAfterAction(id, "running")
 	return nil
 }
 
-// StopContainer stops a container and waits for it to exit
-func (s *ContainerService) StopContainer(ctx context.Context, id string) error {
-	// Get existing container data first
-	existing, exists := s.store.Get(id)
-	if !exists {
-		// If container doesn't exist in store, try to get it from Docker
-		containers, err := s.client.ListContainers(ctx, true)
-		if err == nil {
-			for _, c := range containers {
-				if c.ID == id {
-					existing = store.ContainerData{
-						ID:      c.ID,
-						Names:   c.Names,
-						Image:   c.Image,
-						Created: c.Created,
-					}
-					break
-				}
-			}
-		}
+// StopContainer stops a container and waits for it to exit. A timeout or signal left
+// unset in opts defaults to the container's duh.stop-timeout and duh.stop-signal
+// labels. It fails with a ConflictError while another action is in progress on the
+// container.
+func (s *ContainerService) StopContainer(ctx context.Context, id string, opts docker.StopOptions) error {
+	done, err := s.beginOperation(ctx, id, "stop")
+	if err != nil {
+		return err
 	}
+	defer done()
+
+	return s.stopContainer(ctx, id, opts)
+}
+
+func (s *ContainerService) stopContainer(ctx context.Context, id string, opts docker.StopOptions) error {
+	existing, err := s.actionTarget(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	opts = stopOptions(existing.Labels, opts)
+	window := s.stopWindow(opts)
 
 	// Set intermediate state while preserving other fields
 	existing.State = store.StateStopping
 	existing.Status = "Stopping" // Add status to show in UI
+	existing.Error = ""
+	deadline := time.Now().Add(window)
+	existing.Deadline = &deadline
 	s.store.Update(existing)
 
+	// Bound the Docker call so a hanging daemon cannot hold the request forever
+	callCtx, cancel := context.WithTimeout(ctx, window)
+	defer cancel()
+
 	// Send stop command
-	err := s.client.StopContainer(ctx, id)
+	err = s.client.StopContainer(callCtx, id, opts)
+	s.metrics.dockerError(OpStop, err)
 	if err != nil {
+		// Keep the error on the record in case the transition never resolves
+		existing.Error = err.Error()
+		s.store.Update(existing)
+
 		// On error, try to get current state from Docker
 		containers, listErr := s.client.ListContainers(ctx, true)
 		if listErr == nil {
@@ -274,67 +493,105 @@ func (s *ContainerService) StopContainer(ctx context.Context, id string) error {
 				if c.ID == id {
 					existing.State = c.State
 					existing.Status = c.Status
+					existing.Deadline = nil
 					s.store.Update(existing)
 					break
 				}
 			}
 		}
+		s.recordAction(id, "stop", err)
 		return err
 	}
 
-	// Let the next Sync update pick up the final state
+	// Refresh the container now, or let the next Sync update pick up the final state
+	s.recordAction(id, "stop", nil)
+	s.refreshAfterAction(id, "exited")
 	return nil
 }
 
-// sortContainers sorts containers by status (running > stopping > starting > exited),
-// then by memory usage (desc), and finally by creation time (desc)
-func sortContainers(containers []store.ContainerData) {
-	sort.Slice(containers, func(i, j int) bool {
-		// First sort by status priority
-		iPriority := getStatusPriority(containers[i].State)
-		jPriority := getStatusPriority(containers[j].State)
-		if iPriority != jPriority {
-			return iPriority < jPriority // Lower number = higher priority
-		}
+// recordAction stores the outcome of an action on the container record so that every
+// client sees why an action failed until the next action replaces it
+func (s *ContainerService) recordAction(id, action string, err error) {
+	result := store.ActionResult{
+		Action:  action,
+		Outcome: store.OutcomeSucceeded,
+		At:      time.Now(),
+	}
+	if err != nil {
+		result.Outcome = store.OutcomeFailed
+		result.Error = err.Error()
+	}
+	s.store.RecordAction(id, result)
+}
 
-		// If status is the same, sort by memory usage
-		// Only compare memory if both containers have stats
-		if containers[i].Stats != nil && containers[j].Stats != nil {
-			if containers[i].Stats.Memory.Usage != containers[j].Stats.Memory.Usage {
-				return containers[i].Stats.Memory.Usage > containers[j].Stats.Memory.Usage
-			}
-		} else if containers[i].Stats != nil {
-			return true // Container with stats comes first
-		} else if containers[j].Stats != nil {
-			return false // Container with stats comes first
-		}
+// StreamLogs follows the output of a container, starting with the last tail lines,
+// and calls fn for every line until ctx is cancelled, the log stream ends or fn fails
+func (s *ContainerService) StreamLogs(ctx context.Context, id string, tail int, fn func(docker.LogLine) error) error {
+	logs, err := s.client.ContainerLogs(ctx, id, docker.LogOptions{Follow: true, Tail: tail})
+	if err != nil {
+		s.metrics.dockerError(OpLogs, err)
+		return err
+	}
+	defer func() { _ = logs.Close() }()
 
-		// If memory usage is the same or no stats, sort by creation time desc
-		return containers[i].Created > containers[j].Created
-	})
+	if err := docker.ReadLogLines(logs, fn); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
 }
 
-// getStatusPriority returns a priority number for sorting container states
-// Lower number = higher priority
-func getStatusPriority(state string) int {
-	switch state {
-	case "running":
+// getStatusPriority returns a priority number for sorting container states.
+// Lower number = higher priority; unhealthy containers come first as they need attention.
+func getStatusPriority(c store.ContainerData) int {
+	if c.State == "running" && c.Health != nil && c.Health.Status == store.HealthUnhealthy {
 		return 0
-	case store.StateStopping:
+	}
+	switch c.State {
+	case "running":
 		return 1
-	case store.StateStarting:
+	case store.StateError:
 		return 2
-	case "exited":
+	case store.StateStopping:
 		return 3
-	default:
+	case store.StateStarting:
 		return 4
+	case "exited":
+		return 5
+	default:
+		return 6
+	}
+}
+
+// ListOption configures List
+type ListOption func(*listOptions)
+
+type listOptions struct {
+	filters []Filter
+	sort    []SortKey
+}
+
+// WithFilters limits List to containers matching every filter
+func WithFilters(filters ...Filter) ListOption {
+	return func(o *listOptions) {
+		o.filters = append(o.filters, filters...)
 	}
 }
 
-// List returns all container data from the store, sorted by memory usage and creation time
-func (s *ContainerService) List() []store.ContainerData {
-	containers := s.store.List()
-	sortContainers(containers)
+// WithSort orders List by the given keys instead of DefaultSort
+func WithSort(keys ...SortKey) ListOption {
+	return func(o *listOptions) {
+		o.sort = keys
+	}
+}
+
+// List returns the stored containers, by default all of them in DefaultSort order
+func (s *ContainerService) List(opts ...ListOption) []store.ContainerData {
+	var o listOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	containers := FilterContainers(s.store.List(), o.filters...)
+	SortContainers(containers, o.sort...)
 	return containers
 }
 
@@ -342,3 +599,34 @@ func (s *ContainerService) List() []store.ContainerData {
 func (s *ContainerService) Get(id string) (store.ContainerData, bool) {
 	return s.store.Get(id)
 }
+
+// Subscribe returns a channel of store change events, see store.Store.Subscribe
+func (s *ContainerService) Subscribe(ctx context.Context, buffer int) <-chan store.Event {
+	return s.store.Subscribe(ctx, buffer)
+}
+
+// EventsSince returns the retained change events after seq, see store.Store.EventsSince
+func (s *ContainerService) EventsSince(seq uint64) ([]store.Event, bool) {
+	return s.store.EventsSince(seq)
+}
+
+// Snapshot returns the sorted container list and the sequence number of the last event it reflects
+func (s *ContainerService) Snapshot() ([]store.ContainerData, uint64) {
+	containers, seq := s.store.Snapshot()
+	SortContainers(containers)
+	return containers, seq
+}
+
+// Changes returns the sorted containers changed and the IDs removed after version,
+// along with the current version. The boolean is false when a full list is required.
+func (s *ContainerService) Changes(version uint64) ([]store.ContainerData, []string, uint64, bool) {
+	changed, removed, current, ok := s.store.Since(version)
+	SortContainers(changed)
+	sort.Strings(removed)
+	return changed, removed, current, ok
+}
+
+// Removed returns tombstones of recently removed containers, most recent first
+func (s *ContainerService) Removed() []store.ContainerData {
+	return s.store.Tombstones()
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -128,7 +129,7 @@ func TestServiceStartStop(t *testing.T) {
 		StartContainerFunc: func(ctx context.Context, id string) error {
 			return nil
 		},
-		StopContainerFunc: func(ctx context.Context, id string) error {
+		StopContainerFunc: func(ctx context.Context, id string, opts docker.StopOptions) error {
 			return nil
 		},
 		ListContainersFunc: func(ctx context.Context, all bool) ([]docker.Container, error) {
@@ -167,7 +168,7 @@ func TestServiceStartStop(t *testing.T) {
 	}
 
 	// Test StopContainer
-	err = service.StopContainer(context.Background(), "test-id")
+	err = service.StopContainer(context.Background(), "test-id", docker.StopOptions{})
 	if err != nil {
 		t.Errorf("StopContainer failed: %v", err)
 	}
@@ -190,7 +191,7 @@ func TestServiceStartStopStates(t *testing.T) {
 		StartContainerFunc: func(ctx context.Context, id string) error {
 			return nil
 		},
-		StopContainerFunc: func(ctx context.Context, id string) error {
+		StopContainerFunc: func(ctx context.Context, id string, opts docker.StopOptions) error {
 			return nil
 		},
 		ListContainersFunc: func(ctx context.Context, all bool) ([]docker.Container, error) {
@@ -223,7 +224,7 @@ func TestServiceStartStopStates(t *testing.T) {
 	}
 
 	// Test StopContainer state transition
-	err = service.StopContainer(context.Background(), "test-id")
+	err = service.StopContainer(context.Background(), "test-id", docker.StopOptions{})
 	if err != nil {
 		t.Errorf("StopContainer failed: %v", err)
 	}
@@ -456,7 +457,7 @@ func TestSortContainers(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			sortContainers(tc.input)
+			SortContainers(tc.input)
 
 			// Verify the order
 			for i, expectedID := range tc.expected {
@@ -468,3 +469,311 @@ func TestSortContainers(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceSyncRemovesMissingContainers(t *testing.T) {
+	present := true
+	mockDocker := &DockerClientMock{
+		ListContainersFunc: func(ctx context.Context, all bool) ([]docker.Container, error) {
+			if !present {
+				return nil, nil
+			}
+			return []docker.Container{
+				{
+					ID:     "gone",
+					State:  "exited",
+					Status: "Exited (137) 2 minutes ago",
+				},
+			}, nil
+		},
+	}
+
+	memoryStore := store.NewStore(time.Minute)
+	service := New(mockDocker, memoryStore)
+
+	if _, err := service.SyncContainers(context.Background()); err != nil {
+		t.Fatalf("SyncContainers failed: %v", err)
+	}
+	container, exists := service.Get("gone")
+	if !exists {
+		t.Fatal("Container not found after sync")
+	}
+	if container.ExitCode == nil || *container.ExitCode != 137 {
+		t.Errorf("Expected exit code 137, got %v", container.ExitCode)
+	}
+
+	present = false
+	if _, err := service.SyncContainers(context.Background()); err != nil {
+		t.Fatalf("SyncContainers failed: %v", err)
+	}
+	if _, exists := service.Get("gone"); exists {
+		t.Error("Expected removed container to be gone")
+	}
+
+	removed := service.Removed()
+	if len(removed) != 1 || removed[0].ID != "gone" || *removed[0].ExitCode != 137 {
+		t.Errorf("Expected tombstone with exit code for removed container, got %+v", removed)
+	}
+}
+
+func TestServiceTransitionTimeout(t *testing.T) {
+	mockDocker := &DockerClientMock{
+		StartContainerFunc: func(ctx context.Context, id string) error {
+			return nil
+		},
+		ListContainersFunc: func(ctx context.Context, all bool) ([]docker.Container, error) {
+			// The daemon never reports the container as running
+			return []docker.Container{
+				{
+					ID:     "stuck",
+					State:  "exited",
+					Status: "Exited (1) 1 second ago",
+				},
+			}, nil
+		},
+	}
+
+	memoryStore := store.NewStore(time.Minute)
+	service := New(mockDocker, memoryStore, WithTransitionTimeout(10*time.Millisecond))
+
+	if err := service.StartContainer(context.Background(), "stuck"); err != nil {
+		t.Fatalf("StartContainer failed: %v", err)
+	}
+	container, _ := service.Get("stuck")
+	if container.State != store.StateStarting || container.Deadline == nil {
+		t.Fatalf("Expected starting state with deadline, got %+v", container)
+	}
+
+	// Before the deadline the transition is preserved
+	if _, err := service.SyncContainers(context.Background()); err != nil {
+		t.Fatalf("SyncContainers failed: %v", err)
+	}
+	if container, _ := service.Get("stuck"); container.State != store.StateStarting {
+		t.Errorf("Expected state %s before deadline, got %s", store.StateStarting, container.State)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if _, err := service.SyncContainers(context.Background()); err != nil {
+		t.Fatalf("SyncContainers failed: %v", err)
+	}
+	container, _ = service.Get("stuck")
+	if container.State != store.StateError || container.Error == "" {
+		t.Errorf("Expected error state with message after deadline, got state %s error %q", container.State, container.Error)
+	}
+
+	// The next successful sync clears the error
+	if _, err := service.SyncContainers(context.Background()); err != nil {
+		t.Fatalf("SyncContainers failed: %v", err)
+	}
+	container, _ = service.Get("stuck")
+	if container.State != "exited" || container.Error != "" {
+		t.Errorf("Expected exited state without error, got state %s error %q", container.State, container.Error)
+	}
+}
+
+func TestServiceTransitionOutlivesStoreTTL(t *testing.T) {
+	mockDocker := &DockerClientMock{
+		StartContainerFunc: func(ctx context.Context, id string) error {
+			return nil
+		},
+		ListContainersFunc: func(ctx context.Context, all bool) ([]docker.Container, error) {
+			return []docker.Container{{ID: "stuck", State: "exited", Status: "Exited (1) 1 second ago"}}, nil
+		},
+	}
+
+	// The transition timeout is longer than the store TTL, as with the real defaults
+	memoryStore := store.NewStore(10 * time.Millisecond)
+	service := New(mockDocker, memoryStore, WithTransitionTimeout(50*time.Millisecond))
+
+	if err := service.StartContainer(context.Background(), "stuck"); err != nil {
+		t.Fatalf("StartContainer failed: %v", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if _, err := service.SyncContainers(context.Background()); err != nil {
+		t.Fatalf("SyncContainers failed: %v", err)
+	}
+	if container, _ := service.Get("stuck"); container.State != store.StateStarting || container.Deadline == nil {
+		t.Fatalf("Expected the transition to survive the store TTL, got state %s", container.State)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if _, err := service.SyncContainers(context.Background()); err != nil {
+		t.Fatalf("SyncContainers failed: %v", err)
+	}
+	if container, _ := service.Get("stuck"); container.State != store.StateError || container.Error == "" {
+		t.Errorf("Expected error state after deadline, got state %s error %q", container.State, container.Error)
+	}
+}
+
+func TestServiceStartContainerError(t *testing.T) {
+	mockDocker := &DockerClientMock{
+		StartContainerFunc: func(ctx context.Context, id string) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		ListContainersFunc: func(ctx context.Context, all bool) ([]docker.Container, error) {
+			return nil, errors.New("daemon unavailable")
+		},
+	}
+
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(store.ContainerData{ID: "hung", State: "exited"})
+	service := New(mockDocker, memoryStore, WithTransitionTimeout(10*time.Millisecond))
+
+	if err := service.StartContainer(context.Background(), "hung"); err == nil {
+		t.Fatal("Expected StartContainer to fail when the Docker call hangs")
+	}
+
+	container, _ := service.Get("hung")
+	if container.Error == "" {
+		t.Error("Expected Docker error to be attached to the container")
+	}
+
+	memoryStore.ExpireTransitions(time.Now())
+	container, _ = service.Get("hung")
+	if container.State != store.StateError {
+		t.Errorf("Expected state %s, got %s", store.StateError, container.State)
+	}
+}
+
+func TestServiceRecordsLastAction(t *testing.T) {
+	startErr := errors.New("port is already allocated")
+	mockDocker := &DockerClientMock{
+		StartContainerFunc: func(ctx context.Context, id string) error {
+			return startErr
+		},
+		StopContainerFunc: func(ctx context.Context, id string, opts docker.StopOptions) error {
+			return nil
+		},
+		ListContainersFunc: func(ctx context.Context, all bool) ([]docker.Container, error) {
+			return []docker.Container{
+				{
+					ID:     "web",
+					State:  "exited",
+					Status: "Exited (0) 1 minute ago",
+				},
+			}, nil
+		},
+	}
+
+	service := New(mockDocker, store.NewStore(time.Minute))
+
+	if err := service.StartContainer(context.Background(), "web"); err == nil {
+		t.Fatal("Expected StartContainer to fail")
+	}
+
+	container, _ := service.Get("web")
+	if container.LastAction == nil {
+		t.Fatal("Expected last action to be recorded")
+	}
+	if container.LastAction.Action != "start" || container.LastAction.Outcome != store.OutcomeFailed ||
+		container.LastAction.Error != startErr.Error() {
+		t.Errorf("Unexpected last action %+v", container.LastAction)
+	}
+
+	// The failure survives syncs
+	if err := service.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if container, _ := service.Get("web"); container.LastAction == nil || container.LastAction.Outcome != store.OutcomeFailed {
+		t.Error("Expected failed action to survive sync")
+	}
+
+	// A successful action replaces it
+	if err := service.StopContainer(context.Background(), "web", docker.StopOptions{}); err != nil {
+		t.Fatalf("StopContainer failed: %v", err)
+	}
+	container, _ = service.Get("web")
+	if container.LastAction.Action != "stop" || container.LastAction.Outcome != store.OutcomeSucceeded || container.LastAction.Error != "" {
+		t.Errorf("Expected successful stop to replace failure, got %+v", container.LastAction)
+	}
+}
+
+func TestServiceStopOptions(t *testing.T) {
+	mockDocker := &DockerClientMock{
+		StopContainerFunc: func(ctx context.Context, id string, opts docker.StopOptions) error {
+			return nil
+		},
+	}
+
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(store.ContainerData{
+		ID:     "db",
+		State:  "running",
+		Labels: map[string]string{StopTimeoutLabel: "2m", StopSignalLabel: "SIGINT"},
+	})
+	memoryStore.Update(store.ContainerData{ID: "web", State: "running"})
+
+	service := New(mockDocker, memoryStore)
+
+	// Labels provide the defaults
+	if err := service.StopContainer(context.Background(), "db", docker.StopOptions{}); err != nil {
+		t.Fatalf("StopContainer failed: %v", err)
+	}
+	// Explicit options override the labels
+	timeout := 5
+	if err := service.StopContainer(context.Background(), "db", docker.StopOptions{Timeout: &timeout}); err != nil {
+		t.Fatalf("StopContainer failed: %v", err)
+	}
+	// Without labels nothing is set and Docker's defaults apply
+	if err := service.StopContainer(context.Background(), "web", docker.StopOptions{}); err != nil {
+		t.Fatalf("StopContainer failed: %v", err)
+	}
+
+	calls := mockDocker.StopContainerCalls()
+	if len(calls) != 3 {
+		t.Fatalf("Expected 3 calls to StopContainer, got %d", len(calls))
+	}
+	if opts := calls[0].Opts; opts.Timeout == nil || *opts.Timeout != 120 || opts.Signal != "SIGINT" {
+		t.Errorf("Expected label defaults, got %+v", opts)
+	}
+	if opts := calls[1].Opts; opts.Timeout == nil || *opts.Timeout != 5 || opts.Signal != "SIGINT" {
+		t.Errorf("Expected explicit timeout with label signal, got %+v", opts)
+	}
+	if opts := calls[2].Opts; opts.Timeout != nil || opts.Signal != "" {
+		t.Errorf("Expected empty options, got %+v", opts)
+	}
+}
+
+func TestParseStopTimeout(t *testing.T) {
+	testCases := []struct {
+		value string
+		want  int
+		ok    bool
+	}{
+		{"30", 30, true},
+		{"0", 0, true},
+		{"90s", 90, true},
+		{"1500ms", 2, true},
+		{"", 0, false},
+		{"-1", 0, false},
+		{"soon", 0, false},
+	}
+
+	for _, tc := range testCases {
+		got, ok := parseStopTimeout(tc.value)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("parseStopTimeout(%q) = %d, %v, want %d, %v", tc.value, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestServiceSyncHook(t *testing.T) {
+	mockDocker := &DockerClientMock{
+		ListContainersFunc: func(ctx context.Context, all bool) ([]docker.Container, error) {
+			return []docker.Container{{ID: "web", State: "exited"}}, nil
+		},
+	}
+
+	var got []store.ContainerData
+	service := New(mockDocker, store.NewStore(time.Minute), WithSyncHook(func(ctx context.Context, containers []store.ContainerData) {
+		got = containers
+	}))
+
+	if err := service.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != "web" {
+		t.Errorf("Expected hook to receive the synced container, got %+v", got)
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// 2024-03-15 is a Friday
+	from := time.Date(2024, 3, 15, 18, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 3, 15, 18, 31, 0, 0, time.UTC)},
+		{"0 19 * * mon-fri", time.Date(2024, 3, 15, 19, 0, 0, 0, time.UTC)},
+		{"0 9 * * MON-FRI", time.Date(2024, 3, 18, 9, 0, 0, 0, time.UTC)},
+		{"*/20 * * * *", time.Date(2024, 3, 15, 18, 40, 0, 0, time.UTC)},
+		{"15/30 * * * *", time.Date(2024, 3, 15, 18, 45, 0, 0, time.UTC)},
+		{"0 0,12 * * *", time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * sat", time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC)}, // day of month or week
+		{"0 0 29 feb *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for _, tc := range testCases {
+		cron, err := ParseCron(tc.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q): %v", tc.expr, err)
+			continue
+		}
+		if got := cron.Next(from); !got.Equal(tc.want) {
+			t.Errorf("%q.Next(%v) = %v, want %v", tc.expr, from, got, tc.want)
+		}
+	}
+}
+
+func TestCronNextInLocation(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("timezone data unavailable")
+	}
+	cron, err := ParseCron("30 2 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 02:30 does not exist on the day clocks go forward
+	got := cron.Next(time.Date(2024, 3, 30, 12, 0, 0, 0, loc))
+	want := time.Date(2024, 4, 1, 2, 30, 0, 0, loc)
+	if !got.Equal(want) {
+		t.Errorf("Next() = %v, want %v", got, want)
+	}
+}
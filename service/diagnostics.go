@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/yarlson/duh/docker"
+	"github.com/yarlson/duh/logger"
+	"github.com/yarlson/duh/store"
+)
+
+const (
+	// MaxExitHistory is the number of recent exits kept per container
+	MaxExitHistory = 10
+	// CrashLoopExits failed exits within CrashLoopWindow flag a container as
+	// crash-looping. Clean exits and exits after a kill or stop request don't count.
+	CrashLoopExits  = 3
+	CrashLoopWindow = 5 * time.Minute
+
+	eventsInitialBackoff = time.Second
+	eventsMaxBackoff     = 30 * time.Second
+	inspectTimeout       = 5 * time.Second
+)
+
+// WatchEvents follows the Docker event stream and records container exits, OOM
+// kills and restart counts until ctx is cancelled. It reconnects with backoff when
+// the stream fails.
+func (s *ContainerService) WatchEvents(ctx context.Context) {
+	l := logger.New()
+	backoff := eventsInitialBackoff
+	for {
+		connected := time.Now()
+		err := s.client.Events(ctx, func(ev docker.Event) error {
+			s.handleDockerEvent(ctx, ev)
+			return nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			s.metrics.dockerError(OpEvents, err)
+			l.Warn("Docker event stream failed: %v", err)
+		}
+		if time.Since(connected) > eventsMaxBackoff {
+			backoff = eventsInitialBackoff
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		backoff *= 2
+		if backoff > eventsMaxBackoff {
+			backoff = eventsMaxBackoff
+		}
+	}
+}
+
+func (s *ContainerService) handleDockerEvent(ctx context.Context, ev docker.Event) {
+	if ev.Type != "" && ev.Type != "container" {
+		return
+	}
+	id := ev.Actor.ID
+
+	switch ev.Action {
+	case "oom":
+		s.diagMu.Lock()
+		s.oomKilled[id] = true
+		s.diagMu.Unlock()
+	case "kill", "stop":
+		// Sent before the die event of docker stop, docker restart and compose stop
+		s.diagMu.Lock()
+		s.stopped[id] = true
+		s.diagMu.Unlock()
+	case "start":
+		s.diagMu.Lock()
+		delete(s.stopped, id)
+		s.diagMu.Unlock()
+	case "die":
+		at := time.Unix(0, ev.TimeNano)
+		if ev.TimeNano == 0 {
+			at = time.Unix(ev.Time, 0)
+		}
+		code, _ := strconv.Atoi(ev.Actor.Attributes["exitCode"])
+		s.recordExit(ctx, id, store.ExitRecord{At: at, ExitCode: code})
+	}
+}
+
+// recordExit adds an exit to the container's diagnostics, completing it with the
+// restart count and OOM flag from inspect
+func (s *ContainerService) recordExit(ctx context.Context, id string, exit store.ExitRecord) {
+	s.diagMu.Lock()
+	exit.OOMKilled = s.oomKilled[id]
+	exit.Stopped = s.stopped[id]
+	delete(s.oomKilled, id)
+	s.diagMu.Unlock()
+
+	existing, exists := s.store.Get(id)
+	if !exists {
+		return
+	}
+	d := copyDiagnostics(existing.Diagnostics)
+
+	inspectCtx, cancel := context.WithTimeout(ctx, inspectTimeout)
+	defer cancel()
+	inspect, err := s.client.InspectContainer(inspectCtx, id)
+	switch {
+	case err == nil:
+		d.RestartCount = inspect.RestartCount
+		exit.OOMKilled = exit.OOMKilled || inspect.State.OOMKilled
+	case errors.Is(err, docker.ErrNotFound):
+		return // removed right after exiting, e.g. --rm
+	default:
+		s.metrics.dockerError(OpInspect, err)
+	}
+
+	d.OOMKilled = exit.OOMKilled
+	d.Exits = append(d.Exits, exit)
+	if len(d.Exits) > MaxExitHistory {
+		d.Exits = d.Exits[len(d.Exits)-MaxExitHistory:]
+	}
+	diagnose(d, existing.State == "running", time.Now())
+	s.store.SetDiagnostics(id, d)
+}
+
+// stopRequested reports whether a container was sent a kill or stop, by duh or any
+// other client, since it last started
+func (s *ContainerService) stopRequested(id string) bool {
+	s.diagMu.Lock()
+	defer s.diagMu.Unlock()
+	return s.stopped[id]
+}
+
+// rediagnose clears problems whose exits have aged out of the crash loop window
+func (s *ContainerService) rediagnose(now time.Time) {
+	for _, c := range s.store.List() {
+		if c.Diagnostics == nil {
+			continue
+		}
+		d := copyDiagnostics(c.Diagnostics)
+		diagnose(d, c.State == "running", now)
+		if d.Problem != c.Diagnostics.Problem || d.Reason != c.Diagnostics.Reason {
+			s.store.SetDiagnostics(c.ID, d)
+		}
+	}
+}
+
+// diagnose sets the problem of a container from its recent exits. Crash loops take
+// precedence over OOM kills; an OOM kill is reported while the container is down or
+// until it has been up for CrashLoopWindow.
+func diagnose(d *store.Diagnostics, running bool, now time.Time) {
+	d.Problem, d.Reason = "", ""
+	if len(d.Exits) == 0 {
+		return
+	}
+	last := d.Exits[len(d.Exits)-1]
+
+	recent := 0
+	for _, exit := range d.Exits {
+		if exit.ExitCode != 0 && !exit.Stopped && now.Sub(exit.At) <= CrashLoopWindow {
+			recent++
+		}
+	}
+
+	switch {
+	case recent >= CrashLoopExits:
+		d.Problem = store.ProblemCrashLoop
+		d.Reason = fmt.Sprintf("failed %d times in the last %s, last with code %d", recent, CrashLoopWindow, last.ExitCode)
+	case last.OOMKilled && (!running || now.Sub(last.At) <= CrashLoopWindow):
+		d.Problem = store.ProblemOOMKilled
+		d.Reason = fmt.Sprintf("killed for running out of memory at %s", last.At.Format(time.RFC3339))
+	}
+}
+
+func copyDiagnostics(d *store.Diagnostics) *store.Diagnostics {
+	if d == nil {
+		return &store.Diagnostics{}
+	}
+	c := *d
+	c.Exits = append([]store.ExitRecord(nil), d.Exits...)
+	return &c
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/yarlson/duh/docker"
+	"github.com/yarlson/duh/store"
+)
+
+func dieEvent(id string, code int, at time.Time) docker.Event {
+	ev := docker.Event{Type: "container", Action: "die", TimeNano: at.UnixNano()}
+	ev.Actor.ID = id
+	ev.Actor.Attributes = map[string]string{"exitCode": strconv.Itoa(code)}
+	return ev
+}
+
+func containerEvent(id, action string) docker.Event {
+	ev := docker.Event{Type: "container", Action: action}
+	ev.Actor.ID = id
+	return ev
+}
+
+func TestServiceCrashLoopDetection(t *testing.T) {
+	restarts := 0
+	mockDocker := &DockerClientMock{
+		InspectContainerFunc: func(ctx context.Context, id string) (*docker.ContainerInspect, error) {
+			restarts++
+			return &docker.ContainerInspect{ID: id, RestartCount: restarts}, nil
+		},
+	}
+
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(store.ContainerData{ID: "worker", State: "running"})
+	service := New(mockDocker, memoryStore)
+
+	now := time.Now()
+	for i := 0; i < CrashLoopExits; i++ {
+		service.handleDockerEvent(context.Background(), dieEvent("worker", 1, now.Add(time.Duration(i-CrashLoopExits)*20*time.Second)))
+	}
+
+	container, _ := service.Get("worker")
+	d := container.Diagnostics
+	if d == nil {
+		t.Fatal("Expected diagnostics to be recorded")
+	}
+	if d.Problem != store.ProblemCrashLoop || d.Reason == "" {
+		t.Errorf("Expected crash loop with a reason, got %q (%q)", d.Problem, d.Reason)
+	}
+	if d.RestartCount != CrashLoopExits || len(d.Exits) != CrashLoopExits || d.Exits[0].ExitCode != 1 {
+		t.Errorf("Unexpected diagnostics %+v", d)
+	}
+
+	// Diagnostics survive regular updates from the container list
+	memoryStore.Update(store.ContainerData{ID: "worker", State: "running", Status: "Up 5 seconds"})
+	if container, _ := service.Get("worker"); container.Diagnostics == nil {
+		t.Error("Expected diagnostics to survive updates")
+	}
+
+	// Only the most recent exits are kept
+	for i := 0; i < MaxExitHistory; i++ {
+		service.handleDockerEvent(context.Background(), dieEvent("worker", 2, now))
+	}
+	container, _ = service.Get("worker")
+	if len(container.Diagnostics.Exits) != MaxExitHistory || container.Diagnostics.Exits[0].ExitCode != 2 {
+		t.Errorf("Expected the last %d exits, got %+v", MaxExitHistory, container.Diagnostics.Exits)
+	}
+
+	// The problem clears once the exits age out of the window
+	service.rediagnose(now.Add(CrashLoopWindow + time.Minute))
+	container, _ = service.Get("worker")
+	if container.Diagnostics.Problem != "" || len(container.Diagnostics.Exits) != MaxExitHistory {
+		t.Errorf("Expected problem to clear but history to remain, got %+v", container.Diagnostics)
+	}
+
+	// Clean exits and exits following a kill or stop request are not crashes
+	memoryStore.Update(store.ContainerData{ID: "job", State: "running"})
+	memoryStore.Update(store.ContainerData{ID: "web", State: "running"})
+	for i := 0; i < CrashLoopExits+1; i++ {
+		at := now.Add(time.Duration(i-CrashLoopExits-1) * 20 * time.Second)
+
+		// A job finishing cleanly over and over
+		service.handleDockerEvent(context.Background(), dieEvent("job", 0, at))
+
+		// docker restart or docker stop followed by start: kill, die (143), stop, start
+		service.handleDockerEvent(context.Background(), containerEvent("web", "kill"))
+		service.handleDockerEvent(context.Background(), dieEvent("web", 143, at))
+		service.handleDockerEvent(context.Background(), containerEvent("web", "stop"))
+		service.handleDockerEvent(context.Background(), containerEvent("web", "start"))
+	}
+
+	for _, id := range []string{"job", "web"} {
+		container, _ := service.Get(id)
+		if d := container.Diagnostics; d == nil || d.Problem != "" || len(d.Exits) != CrashLoopExits+1 {
+			t.Errorf("Expected %s exits to be recorded without a problem, got %+v", id, d)
+		}
+	}
+	if container, _ := service.Get("web"); !container.Diagnostics.Exits[0].Stopped {
+		t.Error("Expected the exit to be marked as stopped")
+	}
+
+	// Crashes after the last start still count
+	for i := 0; i < CrashLoopExits; i++ {
+		service.handleDockerEvent(context.Background(), dieEvent("web", 1, now))
+	}
+	if container, _ := service.Get("web"); container.Diagnostics.Problem != store.ProblemCrashLoop {
+		t.Errorf("Expected a crash loop, got %+v", container.Diagnostics)
+	}
+}
+
+func TestServiceOOMKillDetection(t *testing.T) {
+	mockDocker := &DockerClientMock{
+		InspectContainerFunc: func(ctx context.Context, id string) (*docker.ContainerInspect, error) {
+			return &docker.ContainerInspect{ID: id}, nil
+		},
+	}
+
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(store.ContainerData{ID: "db", State: "running"})
+	service := New(mockDocker, memoryStore)
+
+	oom := docker.Event{Type: "container", Action: "oom"}
+	oom.Actor.ID = "db"
+	service.handleDockerEvent(context.Background(), oom)
+	service.handleDockerEvent(context.Background(), dieEvent("db", 137, time.Now()))
+
+	container, _ := service.Get("db")
+	d := container.Diagnostics
+	if d == nil || !d.OOMKilled || d.Problem != store.ProblemOOMKilled || !d.Exits[0].OOMKilled || d.Exits[0].ExitCode != 137 {
+		t.Errorf("Expected OOM kill to be flagged, got %+v", d)
+	}
+
+	// A clean exit replaces the OOM flag
+	service.handleDockerEvent(context.Background(), dieEvent("db", 0, time.Now()))
+	container, _ = service.Get("db")
+	if container.Diagnostics.OOMKilled || container.Diagnostics.Problem != "" {
+		t.Errorf("Expected OOM flag to clear, got %+v", container.Diagnostics)
+	}
+}
+
+func TestDiagnoseIgnoredExits(t *testing.T) {
+	mockDocker := &DockerClientMock{
+		InspectContainerFunc: func(ctx context.Context, id string) (*docker.ContainerInspect, error) {
+			return nil, docker.ErrNotFound
+		},
+	}
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(store.ContainerData{ID: "gone", State: "running"})
+	service := New(mockDocker, memoryStore)
+
+	// Exits of containers removed right away are ignored
+	service.handleDockerEvent(context.Background(), dieEvent("gone", 1, time.Now()))
+	if container, _ := service.Get("gone"); container.Diagnostics != nil {
+		t.Errorf("Expected no diagnostics, got %+v", container.Diagnostics)
+	}
+
+	d := &store.Diagnostics{}
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < MaxExitHistory+5; i++ {
+		d.Exits = append(d.Exits, store.ExitRecord{At: start.Add(time.Duration(i) * time.Minute)})
+	}
+	diagnose(d, true, time.Now())
+	if d.Problem != "" {
+		t.Errorf("Expected old exits not to count as a crash loop, got %q", d.Problem)
+	}
+}
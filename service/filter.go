@@ -0,0 +1,125 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/yarlson/duh/store"
+)
+
+// Filter reports whether a container should be included in a listing
+type Filter func(store.ContainerData) bool
+
+// MatchText matches containers whose ID, image or one of whose names contains text,
+// ignoring case
+func MatchText(text string) Filter {
+	text = strings.ToLower(text)
+	return func(c store.ContainerData) bool {
+		if strings.Contains(strings.ToLower(c.ID), text) || strings.Contains(strings.ToLower(c.Image), text) {
+			return true
+		}
+		for _, name := range c.Names {
+			if strings.Contains(strings.ToLower(strings.TrimPrefix(name, "/")), text) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchID matches containers whose ID starts with prefix
+func MatchID(prefix string) Filter {
+	return func(c store.ContainerData) bool {
+		return strings.HasPrefix(c.ID, prefix)
+	}
+}
+
+// MatchState matches containers in any of the given states
+func MatchState(states ...string) Filter {
+	return func(c store.ContainerData) bool {
+		for _, state := range states {
+			if c.State == state {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchLabel matches containers that have a label, given as "key" or "key=value"
+func MatchLabel(label string) Filter {
+	sel := Selector{Label: label}
+	return sel.Match
+}
+
+// MatchImage matches the image against a glob pattern. A pattern without a tag
+// also matches every tag of the image, so "redis" matches "redis:7".
+func MatchImage(pattern string) Filter {
+	return func(c store.ContainerData) bool {
+		if globMatch(pattern, c.Image) {
+			return true
+		}
+		repo := c.Image
+		if i := strings.LastIndexByte(repo, ':'); i > strings.LastIndexByte(repo, '/') {
+			repo = repo[:i]
+		}
+		return globMatch(pattern, repo)
+	}
+}
+
+// MatchName matches any container name against a glob pattern
+func MatchName(pattern string) Filter {
+	return func(c store.ContainerData) bool {
+		return matchAnyName(pattern, c.Names)
+	}
+}
+
+// MatchProject matches containers of a Docker Compose project
+func MatchProject(name string) Filter {
+	return func(c store.ContainerData) bool {
+		return c.Compose != nil && c.Compose.Project == name
+	}
+}
+
+// MatchAll matches containers that satisfy every filter
+func MatchAll(filters ...Filter) Filter {
+	return func(c store.ContainerData) bool {
+		for _, filter := range filters {
+			if !filter(c) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func matchAny(filters ...Filter) Filter {
+	return func(c store.ContainerData) bool {
+		for _, filter := range filters {
+			if filter(c) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func not(filter Filter) Filter {
+	return func(c store.ContainerData) bool {
+		return !filter(c)
+	}
+}
+
+// FilterContainers returns the containers that satisfy every filter, keeping their order
+func FilterContainers(containers []store.ContainerData, filters ...Filter) []store.ContainerData {
+	if len(filters) == 0 {
+		return containers
+	}
+	match := MatchAll(filters...)
+	filtered := make([]store.ContainerData, 0, len(containers))
+	for _, c := range containers {
+		if match(c) {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/yarlson/duh/docker"
+	"github.com/yarlson/duh/store"
+)
+
+// HealthLogSize is the number of healthcheck probe results kept per container
+const HealthLogSize = 5
+
+// parseHealth extracts the healthcheck status from a Docker status such as
+// "Up 2 hours (unhealthy)". It returns nil for containers without a healthcheck.
+func parseHealth(status string) *store.Health {
+	switch {
+	case strings.HasSuffix(status, "(healthy)"):
+		return &store.Health{Status: store.HealthHealthy}
+	case strings.HasSuffix(status, "(unhealthy)"):
+		return &store.Health{Status: store.HealthUnhealthy}
+	case strings.HasSuffix(status, "(health: starting)"):
+		return &store.Health{Status: store.HealthStarting}
+	}
+	return nil
+}
+
+// SyncHealth inspects running containers with a healthcheck and stores their failing
+// streak and most recent probe results
+func (s *ContainerService) SyncHealth(ctx context.Context, containers []docker.Container) {
+	var wg sync.WaitGroup
+	for _, c := range containers {
+		if c.State != "running" || parseHealth(c.Status) == nil {
+			continue
+		}
+		if stored, exists := s.store.Get(c.ID); !exists || stored.State != "running" {
+			continue // Skip containers in transition
+		}
+
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			inspectCtx, cancel := context.WithTimeout(ctx, inspectTimeout)
+			defer cancel()
+			inspect, err := s.client.InspectContainer(inspectCtx, id)
+			if err != nil {
+				s.metrics.dockerError(OpInspect, err)
+				return
+			}
+			if inspect.State.Health == nil {
+				return
+			}
+			s.store.SetHealth(id, healthData(inspect.State.Health))
+		}(c.ID)
+	}
+	wg.Wait()
+}
+
+// healthData converts Docker's health state, keeping the last HealthLogSize probes
+func healthData(h *docker.Health) *store.Health {
+	health := &store.Health{Status: h.Status, FailingStreak: h.FailingStreak}
+	probes := h.Log
+	if len(probes) > HealthLogSize {
+		probes = probes[len(probes)-HealthLogSize:]
+	}
+	for _, probe := range probes {
+		health.Log = append(health.Log, store.HealthProbe{
+			Start:    probe.Start,
+			End:      probe.End,
+			ExitCode: probe.ExitCode,
+			Output:   probe.Output,
+		})
+	}
+	return health
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yarlson/duh/docker"
+	"github.com/yarlson/duh/store"
+)
+
+func TestParseHealth(t *testing.T) {
+	testCases := []struct {
+		status string
+		want   string
+	}{
+		{"Up 2 hours (healthy)", store.HealthHealthy},
+		{"Up 2 hours (unhealthy)", store.HealthUnhealthy},
+		{"Up 3 seconds (health: starting)", store.HealthStarting},
+		{"Up 2 hours", ""},
+		{"Exited (0) 1 hour ago", ""},
+	}
+
+	for _, tc := range testCases {
+		health := parseHealth(tc.status)
+		got := ""
+		if health != nil {
+			got = health.Status
+		}
+		if got != tc.want {
+			t.Errorf("parseHealth(%q) = %q, want %q", tc.status, got, tc.want)
+		}
+	}
+}
+
+func TestServiceSyncHealth(t *testing.T) {
+	var probes []docker.HealthLog
+	for i := 0; i < 7; i++ {
+		probes = append(probes, docker.HealthLog{ExitCode: i % 2, Output: fmt.Sprintf("probe %d", i)})
+	}
+	mockDocker := &DockerClientMock{
+		InspectContainerFunc: func(ctx context.Context, id string) (*docker.ContainerInspect, error) {
+			inspect := &docker.ContainerInspect{ID: id}
+			inspect.State.Health = &docker.Health{Status: "unhealthy", FailingStreak: 4, Log: probes}
+			return inspect, nil
+		},
+	}
+
+	containers := []docker.Container{
+		{ID: "api", State: "running", Status: "Up 2 hours (unhealthy)"},
+		{ID: "plain", State: "running", Status: "Up 2 hours"},
+	}
+	memoryStore := store.NewStore(time.Minute)
+	for _, c := range containers {
+		memoryStore.Update(containerData(c))
+	}
+	service := New(mockDocker, memoryStore)
+
+	service.SyncHealth(context.Background(), containers)
+
+	if calls := mockDocker.InspectContainerCalls(); len(calls) != 1 || calls[0].ID != "api" {
+		t.Fatalf("Expected only api to be inspected, got %+v", calls)
+	}
+	api, _ := memoryStore.Get("api")
+	if api.Health == nil || api.Health.FailingStreak != 4 || len(api.Health.Log) != HealthLogSize {
+		t.Fatalf("Unexpected health %+v", api.Health)
+	}
+	if api.Health.Log[0].Output != "probe 2" || api.Health.Log[HealthLogSize-1].ExitCode != 0 {
+		t.Errorf("Expected the most recent probes, got %+v", api.Health.Log)
+	}
+
+	// A later sync with the same status keeps the probe details
+	memoryStore.Update(containerData(containers[0]))
+	if api, _ = memoryStore.Get("api"); len(api.Health.Log) != HealthLogSize {
+		t.Errorf("Expected probe details to be kept, got %+v", api.Health)
+	}
+
+	// Recovering replaces them until the next inspect
+	containers[0].Status = "Up 2 hours (healthy)"
+	memoryStore.Update(containerData(containers[0]))
+	if api, _ = memoryStore.Get("api"); api.Health.Status != store.HealthHealthy || api.Health.Log != nil {
+		t.Errorf("Expected fresh health, got %+v", api.Health)
+	}
+}
+
+func TestSortUnhealthyFirst(t *testing.T) {
+	containers := []store.ContainerData{
+		{ID: "a", State: "exited"},
+		{ID: "b", State: "running", Health: &store.Health{Status: store.HealthHealthy}},
+		{ID: "c", State: store.StateError},
+		{ID: "d", State: "running", Health: &store.Health{Status: store.HealthUnhealthy}},
+		{ID: "e", State: "running"},
+	}
+	SortContainers(containers, SortKey{Field: SortState})
+
+	var ids []string
+	for _, c := range containers {
+		ids = append(ids, c.ID)
+	}
+	if got := strings.Join(ids, ","); got != "d,b,e,c,a" {
+		t.Errorf("Expected d,b,e,c,a, got %s", got)
+	}
+}
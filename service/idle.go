@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/yarlson/duh/docker"
+	"github.com/yarlson/duh/logger"
+	"github.com/yarlson/duh/store"
+)
+
+const (
+	DefaultIdleCPUPercent  = 1.0
+	DefaultIdleNetworkRate = 1024.0 // bytes per second, received and sent combined
+
+	idleLogSize = 200
+)
+
+// IdlePolicy describes when a running container counts as idle
+type IdlePolicy struct {
+	// After is how long a container must stay idle before it is stopped
+	After time.Duration
+	// CPUPercent is the CPU usage below which a container is idle
+	CPUPercent float64
+	// NetworkRate is the combined receive and send rate in bytes per second at or
+	// below which network I/O counts as flat
+	NetworkRate float64
+	// Scope limits the policy to matching containers, for example a query parsed
+	// with ParseQuery such as "project:side OR label:duh.idle-stop". A nil Scope
+	// matches no container.
+	Scope Filter
+	// DryRun only reports candidates instead of stopping them
+	DryRun bool
+}
+
+// IdleCandidate is a container that has been idle for at least the policy's After
+type IdleCandidate struct {
+	ContainerID string    `json:"container_id"`
+	Name        string    `json:"name"`
+	IdleSince   time.Time `json:"idle_since"`
+	CPU         float64   `json:"cpu"`
+	NetworkRate float64   `json:"network_rate"`
+}
+
+// IdleStop records a container stopped for being idle
+type IdleStop struct {
+	ContainerID string    `json:"container_id"`
+	Name        string    `json:"name"`
+	Reason      string    `json:"reason"`
+	Error       string    `json:"error,omitempty"`
+	At          time.Time `json:"at"`
+}
+
+// IdleReport is the state of the idle policy
+type IdleReport struct {
+	DryRun     bool            `json:"dry_run"`
+	Candidates []IdleCandidate `json:"candidates"`
+	Stopped    []IdleStop      `json:"stopped"`
+}
+
+type idleStopper struct {
+	policy IdlePolicy
+
+	mu         sync.Mutex
+	idleSince  map[string]time.Time
+	stopping   map[string]bool
+	candidates []IdleCandidate
+	log        []IdleStop
+	wg         sync.WaitGroup
+}
+
+// WithIdleStop stops running containers in the policy's scope once their CPU usage
+// and network I/O have stayed below the policy thresholds for policy.After. Zero
+// thresholds fall back to DefaultIdleCPUPercent and DefaultIdleNetworkRate.
+func WithIdleStop(policy IdlePolicy) Option {
+	return func(s *ContainerService) {
+		if policy.CPUPercent <= 0 {
+			policy.CPUPercent = DefaultIdleCPUPercent
+		}
+		if policy.NetworkRate <= 0 {
+			policy.NetworkRate = DefaultIdleNetworkRate
+		}
+		s.idle = &idleStopper{
+			policy:    policy,
+			idleSince: make(map[string]time.Time),
+			stopping:  make(map[string]bool),
+		}
+	}
+}
+
+// IdleReport returns the current idle candidates and the containers stopped so far,
+// oldest first
+func (s *ContainerService) IdleReport() IdleReport {
+	report := IdleReport{Candidates: []IdleCandidate{}, Stopped: []IdleStop{}}
+	if s.idle == nil {
+		return report
+	}
+	s.idle.mu.Lock()
+	defer s.idle.mu.Unlock()
+	report.DryRun = s.idle.policy.DryRun
+	report.Candidates = append(report.Candidates, s.idle.candidates...)
+	report.Stopped = append(report.Stopped, s.idle.log...)
+	return report
+}
+
+// runIdleStop updates how long each container has been idle and stops, in the
+// background, those idle for longer than the policy allows
+func (s *ContainerService) runIdleStop(ctx context.Context, now time.Time) {
+	idle := s.idle
+	if idle == nil {
+		return
+	}
+	policy := idle.policy
+
+	idle.mu.Lock()
+	defer idle.mu.Unlock()
+
+	seen := make(map[string]bool)
+	idle.candidates = idle.candidates[:0]
+	for _, c := range s.store.List() {
+		if c.State != "running" || policy.Scope == nil || !policy.Scope(c) {
+			continue
+		}
+		seen[c.ID] = true
+
+		cpu, rate, ok := idleUsage(c, policy)
+		if !ok {
+			delete(idle.idleSince, c.ID)
+			continue
+		}
+		since, tracked := idle.idleSince[c.ID]
+		if !tracked {
+			idle.idleSince[c.ID] = now
+			since = now
+		}
+		if now.Sub(since) < policy.After {
+			continue
+		}
+
+		idle.candidates = append(idle.candidates, IdleCandidate{
+			ContainerID: c.ID,
+			Name:        containerName(c),
+			IdleSince:   since,
+			CPU:         cpu,
+			NetworkRate: rate,
+		})
+		if policy.DryRun || idle.stopping[c.ID] {
+			continue
+		}
+
+		idle.stopping[c.ID] = true
+		reason := fmt.Sprintf("CPU below %.1f%% and network below %.0f B/s since %s",
+			policy.CPUPercent, policy.NetworkRate, since.Format(time.RFC3339))
+		idle.wg.Add(1)
+		go func(c store.ContainerData) {
+			defer idle.wg.Done()
+			s.stopIdle(ctx, c, reason)
+		}(c)
+	}
+
+	// Containers that stopped or left the scope start over when they come back
+	for id := range idle.idleSince {
+		if !seen[id] {
+			delete(idle.idleSince, id)
+		}
+	}
+}
+
+// idleUsage returns the CPU usage and network rate of a container and whether both
+// are below the policy thresholds. Containers without stats are never idle.
+func idleUsage(c store.ContainerData, policy IdlePolicy) (float64, float64, bool) {
+	if c.Stats == nil {
+		return 0, 0, false
+	}
+	cpu := c.Stats.CPU.Usage
+	rate := c.Stats.Network.RxRate + c.Stats.Network.TxRate
+	return cpu, rate, cpu < policy.CPUPercent && rate <= policy.NetworkRate
+}
+
+func (s *ContainerService) stopIdle(ctx context.Context, c store.ContainerData, reason string) {
+	err := s.StopContainer(ctx, c.ID, docker.StopOptions{})
+
+	event := IdleStop{
+		ContainerID: c.ID,
+		Name:        containerName(c),
+		Reason:      reason,
+		At:          time.Now(),
+	}
+	if err != nil {
+		event.Error = err.Error()
+		logger.New().Warn("Idle stop of %s failed: %v", event.Name, err)
+	} else {
+		logger.New().Info("Stopped idle container %s: %s", event.Name, reason)
+	}
+
+	idle := s.idle
+	idle.mu.Lock()
+	defer idle.mu.Unlock()
+	delete(idle.stopping, c.ID)
+	delete(idle.idleSince, c.ID)
+	idle.log = append(idle.log, event)
+	if len(idle.log) > idleLogSize {
+		idle.log = append([]IdleStop(nil), idle.log[len(idle.log)-idleLogSize:]...)
+	}
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yarlson/duh/docker"
+	"github.com/yarlson/duh/store"
+)
+
+func TestServiceIdleStop(t *testing.T) {
+	mockDocker := &DockerClientMock{
+		StopContainerFunc: func(ctx context.Context, id string, opts docker.StopOptions) error {
+			return nil
+		},
+	}
+
+	running := func(id, project string, cpu, rxRate float64) store.ContainerData {
+		c := store.ContainerData{
+			ID:      id,
+			Names:   []string{"/" + id},
+			State:   "running",
+			Compose: &store.ComposeInfo{Project: project},
+			Stats:   &store.Stats{},
+		}
+		c.Stats.CPU.Usage = cpu
+		c.Stats.Network.RxRate = rxRate
+		return c
+	}
+
+	for _, dryRun := range []bool{true, false} {
+		memoryStore := store.NewStore(time.Minute)
+		scope, err := ParseQuery("project:side")
+		if err != nil {
+			t.Fatal(err)
+		}
+		service := New(mockDocker, memoryStore, WithIdleStop(IdlePolicy{
+			After:  time.Hour,
+			Scope:  scope,
+			DryRun: dryRun,
+		}))
+
+		update := func(busy bool) {
+			busyRate := 0.0
+			if busy {
+				busyRate = 50_000
+			}
+			for _, c := range []store.ContainerData{
+				running("idle", "side", 0.2, 10),
+				running("other", "work", 0, 0),
+				running("busy", "side", 0.1, busyRate),
+			} {
+				memoryStore.Update(c)
+				memoryStore.UpdateStats(c.ID, c.Stats) // Update keeps existing stats
+			}
+		}
+
+		now := time.Now()
+		update(false)
+		service.runIdleStop(context.Background(), now)
+		update(true)
+		service.runIdleStop(context.Background(), now.Add(30*time.Minute))
+		update(false)
+		service.runIdleStop(context.Background(), now.Add(time.Hour))
+		service.Close()
+
+		report := service.IdleReport()
+		if report.DryRun != dryRun {
+			t.Errorf("Expected dry run %v", dryRun)
+		}
+		if len(report.Candidates) != 1 || report.Candidates[0].ContainerID != "idle" {
+			t.Fatalf("Expected only idle as a candidate, got %+v", report.Candidates)
+		}
+		if !report.Candidates[0].IdleSince.Equal(now) {
+			t.Errorf("Expected idle since %v, got %v", now, report.Candidates[0].IdleSince)
+		}
+
+		calls := mockDocker.StopContainerCalls()
+		if dryRun {
+			if len(calls) != 0 || len(report.Stopped) != 0 {
+				t.Errorf("Expected no stops in dry run, got %+v", report.Stopped)
+			}
+			continue
+		}
+		if len(calls) != 1 || calls[0].ID != "idle" {
+			t.Fatalf("Expected idle to be stopped, got %+v", calls)
+		}
+		if len(report.Stopped) != 1 || report.Stopped[0].Name != "idle" || report.Stopped[0].Reason == "" {
+			t.Errorf("Unexpected stop record %+v", report.Stopped)
+		}
+	}
+}
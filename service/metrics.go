@@ -0,0 +1,86 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// Docker operations counted by SyncMetrics.DockerErrors
+const (
+	OpList    = "list"
+	OpInspect = "inspect"
+	OpStats   = "stats"
+	OpStart   = "start"
+	OpStop    = "stop"
+	OpLogs    = "logs"
+	OpEvents  = "events"
+)
+
+// SyncMetrics describes the service's own activity for monitoring
+type SyncMetrics struct {
+	Syncs        uint64            // Completed Sync calls, successful or not
+	SyncFailures uint64            // Sync calls that failed to list containers
+	SyncSeconds  float64           // Total time spent in Sync
+	LastSync     time.Duration     // Duration of the most recent Sync
+	LastError    string            // Error of the most recent Sync, empty if it succeeded
+	DockerErrors map[string]uint64 // Failed Docker calls by operation
+}
+
+type metrics struct {
+	mu           sync.Mutex
+	syncs        uint64
+	syncFailures uint64
+	syncSeconds  float64
+	lastSync     time.Duration
+	lastErr      error
+	dockerErrors map[string]uint64
+}
+
+func (m *metrics) observeSync(d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.syncs++
+	if err != nil {
+		m.syncFailures++
+	}
+	m.syncSeconds += d.Seconds()
+	m.lastSync = d
+	m.lastErr = err
+}
+
+// dockerError counts a failed Docker call; nil errors are ignored
+func (m *metrics) dockerError(op string, err error) {
+	if err == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.dockerErrors == nil {
+		m.dockerErrors = make(map[string]uint64)
+	}
+	m.dockerErrors[op]++
+}
+
+// SyncMetrics returns a snapshot of the sync and Docker error counters
+func (s *ContainerService) SyncMetrics() SyncMetrics {
+	m := &s.metrics
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := SyncMetrics{
+		Syncs:        m.syncs,
+		SyncFailures: m.syncFailures,
+		SyncSeconds:  m.syncSeconds,
+		LastSync:     m.lastSync,
+		DockerErrors: make(map[string]uint64, len(m.dockerErrors)),
+	}
+	for op, count := range m.dockerErrors {
+		snapshot.DockerErrors[op] = count
+	}
+	if m.lastErr != nil {
+		snapshot.LastError = m.lastErr.Error()
+	}
+	return snapshot
+}
@@ -5,6 +5,7 @@ package service
 
 import (
 	"context"
+	"io"
 	"sync"
 
 	"github.com/yarlson/duh/docker"
@@ -20,16 +21,28 @@ var _ DockerClient = &DockerClientMock{}
 //
 //		// make and configure a mocked DockerClient
 //		mockedDockerClient := &DockerClientMock{
+//			ContainerLogsFunc: func(ctx context.Context, id string, opts docker.LogOptions) (io.ReadCloser, error) {
+//				panic("mock out the ContainerLogs method")
+//			},
+//			EventsFunc: func(ctx context.Context, fn func(docker.Event) error) error {
+//				panic("mock out the Events method")
+//			},
+//			GetContainerFunc: func(ctx context.Context, id string) (*docker.Container, error) {
+//				panic("mock out the GetContainer method")
+//			},
 //			GetContainerStatsFunc: func(ctx context.Context, id string) (*docker.ContainerStats, error) {
 //				panic("mock out the GetContainerStats method")
 //			},
+//			InspectContainerFunc: func(ctx context.Context, id string) (*docker.ContainerInspect, error) {
+//				panic("mock out the InspectContainer method")
+//			},
 //			ListContainersFunc: func(ctx context.Context, all bool) ([]docker.Container, error) {
 //				panic("mock out the ListContainers method")
 //			},
 //			StartContainerFunc: func(ctx context.Context, id string) error {
 //				panic("mock out the StartContainer method")
 //			},
-//			StopContainerFunc: func(ctx context.Context, id string) error {
+//			StopContainerFunc: func(ctx context.Context, id string, opts docker.StopOptions) error {
 //				panic("mock out the StopContainer method")
 //			},
 //		}
@@ -39,9 +52,21 @@ var _ DockerClient = &DockerClientMock{}
 //
 //	}
 type DockerClientMock struct {
+	// ContainerLogsFunc mocks the ContainerLogs method.
+	ContainerLogsFunc func(ctx context.Context, id string, opts docker.LogOptions) (io.ReadCloser, error)
+
+	// EventsFunc mocks the Events method.
+	EventsFunc func(ctx context.Context, fn func(docker.Event) error) error
+
+	// GetContainerFunc mocks the GetContainer method.
+	GetContainerFunc func(ctx context.Context, id string) (*docker.Container, error)
+
 	// GetContainerStatsFunc mocks the GetContainerStats method.
 	GetContainerStatsFunc func(ctx context.Context, id string) (*docker.ContainerStats, error)
 
+	// InspectContainerFunc mocks the InspectContainer method.
+	InspectContainerFunc func(ctx context.Context, id string) (*docker.ContainerInspect, error)
+
 	// ListContainersFunc mocks the ListContainers method.
 	ListContainersFunc func(ctx context.Context, all bool) ([]docker.Container, error)
 
@@ -49,10 +74,33 @@ type DockerClientMock struct {
 	StartContainerFunc func(ctx context.Context, id string) error
 
 	// StopContainerFunc mocks the StopContainer method.
-	StopContainerFunc func(ctx context.Context, id string) error
+	StopContainerFunc func(ctx context.Context, id string, opts docker.StopOptions) error
 
 	// calls tracks calls to the methods.
 	calls struct {
+		// ContainerLogs holds details about calls to the ContainerLogs method.
+		ContainerLogs []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ID is the id argument value.
+			ID string
+			// Opts is the opts argument value.
+			Opts docker.LogOptions
+		}
+		// Events holds details about calls to the Events method.
+		Events []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Fn is the fn argument value.
+			Fn func(docker.Event) error
+		}
+		// GetContainer holds details about calls to the GetContainer method.
+		GetContainer []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ID is the id argument value.
+			ID string
+		}
 		// GetContainerStats holds details about calls to the GetContainerStats method.
 		GetContainerStats []struct {
 			// Ctx is the ctx argument value.
@@ -60,6 +108,13 @@ type DockerClientMock struct {
 			// ID is the id argument value.
 			ID string
 		}
+		// InspectContainer holds details about calls to the InspectContainer method.
+		InspectContainer []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ID is the id argument value.
+			ID string
+		}
 		// ListContainers holds details about calls to the ListContainers method.
 		ListContainers []struct {
 			// Ctx is the ctx argument value.
@@ -80,14 +135,132 @@ type DockerClientMock struct {
 			Ctx context.Context
 			// ID is the id argument value.
 			ID string
+			// Opts is the opts argument value.
+			Opts docker.StopOptions
 		}
 	}
+	lockContainerLogs     sync.RWMutex
+	lockEvents            sync.RWMutex
+	lockGetContainer      sync.RWMutex
 	lockGetContainerStats sync.RWMutex
+	lockInspectContainer  sync.RWMutex
 	lockListContainers    sync.RWMutex
 	lockStartContainer    sync.RWMutex
 	lockStopContainer     sync.RWMutex
 }
 
+// ContainerLogs calls ContainerLogsFunc.
+func (mock *DockerClientMock) ContainerLogs(ctx context.Context, id string, opts docker.LogOptions) (io.ReadCloser, error) {
+	if mock.ContainerLogsFunc == nil {
+		panic("DockerClientMock.ContainerLogsFunc: method is nil but DockerClient.ContainerLogs was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		ID   string
+		Opts docker.LogOptions
+	}{
+		Ctx:  ctx,
+		ID:   id,
+		Opts: opts,
+	}
+	mock.lockContainerLogs.Lock()
+	mock.calls.ContainerLogs = append(mock.calls.ContainerLogs, callInfo)
+	mock.lockContainerLogs.Unlock()
+	return mock.ContainerLogsFunc(ctx, id, opts)
+}
+
+// ContainerLogsCalls gets all the calls that were made to ContainerLogs.
+// Check the length with:
+//
+//	len(mockedDockerClient.ContainerLogsCalls())
+func (mock *DockerClientMock) ContainerLogsCalls() []struct {
+	Ctx  context.Context
+	ID   string
+	Opts docker.LogOptions
+} {
+	var calls []struct {
+		Ctx  context.Context
+		ID   string
+		Opts docker.LogOptions
+	}
+	mock.lockContainerLogs.RLock()
+	calls = mock.calls.ContainerLogs
+	mock.lockContainerLogs.RUnlock()
+	return calls
+}
+
+// Events calls EventsFunc.
+func (mock *DockerClientMock) Events(ctx context.Context, fn func(docker.Event) error) error {
+	if mock.EventsFunc == nil {
+		panic("DockerClientMock.EventsFunc: method is nil but DockerClient.Events was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Fn  func(docker.Event) error
+	}{
+		Ctx: ctx,
+		Fn:  fn,
+	}
+	mock.lockEvents.Lock()
+	mock.calls.Events = append(mock.calls.Events, callInfo)
+	mock.lockEvents.Unlock()
+	return mock.EventsFunc(ctx, fn)
+}
+
+// EventsCalls gets all the calls that were made to Events.
+// Check the length with:
+//
+//	len(mockedDockerClient.EventsCalls())
+func (mock *DockerClientMock) EventsCalls() []struct {
+	Ctx context.Context
+	Fn  func(docker.Event) error
+} {
+	var calls []struct {
+		Ctx context.Context
+		Fn  func(docker.Event) error
+	}
+	mock.lockEvents.RLock()
+	calls = mock.calls.Events
+	mock.lockEvents.RUnlock()
+	return calls
+}
+
+// GetContainer calls GetContainerFunc.
+func (mock *DockerClientMock) GetContainer(ctx context.Context, id string) (*docker.Container, error) {
+	if mock.GetContainerFunc == nil {
+		panic("DockerClientMock.GetContainerFunc: method is nil but DockerClient.GetContainer was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		ID  string
+	}{
+		Ctx: ctx,
+		ID:  id,
+	}
+	mock.lockGetContainer.Lock()
+	mock.calls.GetContainer = append(mock.calls.GetContainer, callInfo)
+	mock.lockGetContainer.Unlock()
+	return mock.GetContainerFunc(ctx, id)
+}
+
+// GetContainerCalls gets all the calls that were made to GetContainer.
+// Check the length with:
+//
+//	len(mockedDockerClient.GetContainerCalls())
+func (mock *DockerClientMock) GetContainerCalls() []struct {
+	Ctx context.Context
+	ID  string
+} {
+	var calls []struct {
+		Ctx context.Context
+		ID  string
+	}
+	mock.lockGetContainer.RLock()
+	calls = mock.calls.GetContainer
+	mock.lockGetContainer.RUnlock()
+	return calls
+}
+
 // GetContainerStats calls GetContainerStatsFunc.
 func (mock *DockerClientMock) GetContainerStats(ctx context.Context, id string) (*docker.ContainerStats, error) {
 	if mock.GetContainerStatsFunc == nil {
@@ -124,6 +297,42 @@ func (mock *DockerClientMock) GetContainerStatsCalls() []struct {
 	return calls
 }
 
+// InspectContainer calls InspectContainerFunc.
+func (mock *DockerClientMock) InspectContainer(ctx context.Context, id string) (*docker.ContainerInspect, error) {
+	if mock.InspectContainerFunc == nil {
+		panic("DockerClientMock.InspectContainerFunc: method is nil but DockerClient.InspectContainer was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		ID  string
+	}{
+		Ctx: ctx,
+		ID:  id,
+	}
+	mock.lockInspectContainer.Lock()
+	mock.calls.InspectContainer = append(mock.calls.InspectContainer, callInfo)
+	mock.lockInspectContainer.Unlock()
+	return mock.InspectContainerFunc(ctx, id)
+}
+
+// InspectContainerCalls gets all the calls that were made to InspectContainer.
+// Check the length with:
+//
+//	len(mockedDockerClient.InspectContainerCalls())
+func (mock *DockerClientMock) InspectContainerCalls() []struct {
+	Ctx context.Context
+	ID  string
+} {
+	var calls []struct {
+		Ctx context.Context
+		ID  string
+	}
+	mock.lockInspectContainer.RLock()
+	calls = mock.calls.InspectContainer
+	mock.lockInspectContainer.RUnlock()
+	return calls
+}
+
 // ListContainers calls ListContainersFunc.
 func (mock *DockerClientMock) ListContainers(ctx context.Context, all bool) ([]docker.Container, error) {
 	if mock.ListContainersFunc == nil {
@@ -197,21 +406,23 @@ func (mock *DockerClientMock) StartContainerCalls() []struct {
 }
 
 // StopContainer calls StopContainerFunc.
-func (mock *DockerClientMock) StopContainer(ctx context.Context, id string) error {
+func (mock *DockerClientMock) StopContainer(ctx context.Context, id string, opts docker.StopOptions) error {
 	if mock.StopContainerFunc == nil {
 		panic("DockerClientMock.StopContainerFunc: method is nil but DockerClient.StopContainer was just called")
 	}
 	callInfo := struct {
-		Ctx context.Context
-		ID  string
+		Ctx  context.Context
+		ID   string
+		Opts docker.StopOptions
 	}{
-		Ctx: ctx,
-		ID:  id,
+		Ctx:  ctx,
+		ID:   id,
+		Opts: opts,
 	}
 	mock.lockStopContainer.Lock()
 	mock.calls.StopContainer = append(mock.calls.StopContainer, callInfo)
 	mock.lockStopContainer.Unlock()
-	return mock.StopContainerFunc(ctx, id)
+	return mock.StopContainerFunc(ctx, id, opts)
 }
 
 // StopContainerCalls gets all the calls that were made to StopContainer.
@@ -219,12 +430,14 @@ func (mock *DockerClientMock) StopContainer(ctx context.Context, id string) erro
 //
 //	len(mockedDockerClient.StopContainerCalls())
 func (mock *DockerClientMock) StopContainerCalls() []struct {
-	Ctx context.Context
-	ID  string
+	Ctx  context.Context
+	ID   string
+	Opts docker.StopOptions
 } {
 	var calls []struct {
-		Ctx context.Context
-		ID  string
+		Ctx  context.Context
+		ID   string
+		Opts docker.StopOptions
 	}
 	mock.lockStopContainer.RLock()
 	calls = mock.calls.StopContainer
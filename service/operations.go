@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Operation describes an action in progress on a container
+type Operation struct {
+	ContainerID string    `json:"container_id"`
+	Action      string    `json:"action"`
+	StartedAt   time.Time `json:"started_at"`
+}
+
+// ConflictError is returned when an action is requested while a different action
+// is already in progress on the same container
+type ConflictError struct {
+	Action   string
+	InFlight Operation
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("cannot %s container %s: %s in progress since %s",
+		e.Action, e.InFlight.ContainerID, e.InFlight.Action, e.InFlight.StartedAt.Format(time.RFC3339))
+}
+
+type operation struct {
+	Operation
+	done chan struct{}
+}
+
+// beginOperation serializes actions per container. A request for the same action as
+// the one in flight waits for it to finish and then runs; a different action fails
+// with a ConflictError. The returned function must be called when the action is done.
+func (s *ContainerService) beginOperation(ctx context.Context, id, action string) (func(), error) {
+	for {
+		s.opsMu.Lock()
+		inFlight, busy := s.ops[id]
+		if !busy {
+			op := &operation{
+				Operation: Operation{ContainerID: id, Action: action, StartedAt: time.Now()},
+				done:      make(chan struct{}),
+			}
+			s.ops[id] = op
+			s.opsMu.Unlock()
+
+			return func() {
+				s.opsMu.Lock()
+				delete(s.ops, id)
+				s.opsMu.Unlock()
+				close(op.done)
+			}, nil
+		}
+		s.opsMu.Unlock()
+
+		if inFlight.Action != action {
+			return nil, &ConflictError{Action: action, InFlight: inFlight.Operation}
+		}
+
+		select {
+		case <-inFlight.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Operations returns the actions currently in progress, oldest first
+func (s *ContainerService) Operations() []Operation {
+	s.opsMu.Lock()
+	defer s.opsMu.Unlock()
+
+	result := make([]Operation, 0, len(s.ops))
+	for _, op := range s.ops {
+		result = append(result, op.Operation)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].StartedAt.Before(result[j].StartedAt)
+	})
+	return result
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yarlson/duh/docker"
+	"github.com/yarlson/duh/store"
+)
+
+func TestServiceActionConflicts(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	mockDocker := &DockerClientMock{
+		StartContainerFunc: func(ctx context.Context, id string) error {
+			started <- struct{}{}
+			<-release
+			return nil
+		},
+		StopContainerFunc: func(ctx context.Context, id string, opts docker.StopOptions) error {
+			return nil
+		},
+		ListContainersFunc: func(ctx context.Context, all bool) ([]docker.Container, error) {
+			return []docker.Container{{ID: "web", State: "exited"}}, nil
+		},
+	}
+
+	service := New(mockDocker, store.NewStore(time.Minute))
+
+	firstDone := make(chan error, 1)
+	go func() {
+		firstDone <- service.StartContainer(context.Background(), "web")
+	}()
+	<-started
+
+	ops := service.Operations()
+	if len(ops) != 1 || ops[0].ContainerID != "web" || ops[0].Action != "start" {
+		t.Fatalf("Expected one in-flight start, got %+v", ops)
+	}
+
+	// A different action is rejected with a description of the in-flight one
+	err := service.StopContainer(context.Background(), "web", docker.StopOptions{})
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("Expected ConflictError, got %v", err)
+	}
+	if conflict.InFlight.Action != "start" {
+		t.Errorf("Expected in-flight action start, got %s", conflict.InFlight.Action)
+	}
+
+	// The same action waits for the in-flight one and then runs
+	secondDone := make(chan error, 1)
+	go func() {
+		secondDone <- service.StartContainer(context.Background(), "web")
+	}()
+
+	select {
+	case <-secondDone:
+		t.Fatal("Second start did not wait for the first")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-firstDone; err != nil {
+		t.Errorf("First start failed: %v", err)
+	}
+	if err := <-secondDone; err != nil {
+		t.Errorf("Second start failed: %v", err)
+	}
+	if calls := len(mockDocker.StartContainerCalls()); calls != 2 {
+		t.Errorf("Expected 2 serialized start calls, got %d", calls)
+	}
+	if ops := service.Operations(); len(ops) != 0 {
+		t.Errorf("Expected no in-flight operations, got %+v", ops)
+	}
+}
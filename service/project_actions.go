@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yarlson/duh/docker"
+	"github.com/yarlson/duh/store"
+)
+
+// ComposeDependsOnLabel lists the services a Compose service depends on, as
+// comma separated service:condition:restart entries
+const ComposeDependsOnLabel = "com.docker.compose.depends_on"
+
+// Conditions a Compose service can wait for on a dependency before it starts
+const (
+	ConditionStarted   = "service_started"
+	ConditionHealthy   = "service_healthy"
+	ConditionCompleted = "service_completed_successfully"
+)
+
+// ErrProjectNotFound is returned for actions on an unknown Compose project
+var ErrProjectNotFound = errors.New("project not found")
+
+// ProjectStep reports the outcome of one action on the containers of one service
+type ProjectStep struct {
+	Service string       `json:"service"`
+	Action  string       `json:"action"`
+	Status  string       `json:"status"`
+	Error   string       `json:"error,omitempty"`
+	Results []BulkResult `json:"results,omitempty"`
+}
+
+// ProjectAction starts, stops or restarts every container of a Compose project one
+// service at a time. Services start after the services they depend on and stop in
+// the reverse order; a restart stops the whole project before starting it again.
+// Before a service starts, its service_healthy and service_completed_successfully
+// dependencies are awaited for up to the transition timeout. A service whose
+// dependency failed to start or never met its condition is skipped. It returns one
+// step per service and action in the order they ran.
+func (s *ContainerService) ProjectAction(ctx context.Context, name, action string, opts docker.StopOptions) ([]ProjectStep, error) {
+	if action != "start" && action != "stop" && action != "restart" {
+		return nil, ErrInvalidAction
+	}
+	project, ok := s.Project(name)
+	if !ok {
+		return nil, ErrProjectNotFound
+	}
+
+	deps := serviceDependencies(project)
+	services, order := projectServices(project, deps)
+
+	var steps []ProjectStep
+	if action == "stop" || action == "restart" {
+		for i := len(order) - 1; i >= 0; i-- {
+			steps = append(steps, s.serviceStep(ctx, order[i], services[order[i]], "stop", opts))
+		}
+	}
+	if action == "start" || action == "restart" {
+		failed := make(map[string]bool)
+		for _, service := range order {
+			if dep := failedDependency(deps[service], failed); dep != "" {
+				failed[service] = true
+				steps = append(steps, ProjectStep{
+					Service: service,
+					Action:  "start",
+					Status:  BulkSkipped,
+					Error:   "dependency " + dep + " failed",
+				})
+				continue
+			}
+			if err := s.awaitDependencies(ctx, deps[service], services); err != nil {
+				failed[service] = true
+				steps = append(steps, ProjectStep{
+					Service: service,
+					Action:  "start",
+					Status:  BulkSkipped,
+					Error:   err.Error(),
+				})
+				continue
+			}
+			step := s.serviceStep(ctx, service, services[service], "start", opts)
+			if step.Status == BulkFailed {
+				failed[service] = true
+			}
+			steps = append(steps, step)
+		}
+	}
+	return steps, nil
+}
+
+func (s *ContainerService) serviceStep(ctx context.Context, service string, ids []string, action string, opts docker.StopOptions) ProjectStep {
+	step := ProjectStep{Service: service, Action: action}
+	results, err := s.BulkAction(ctx, BulkRequest{
+		Action:  action,
+		IDs:     ids,
+		Timeout: opts.Timeout,
+		Signal:  opts.Signal,
+	})
+	if err != nil {
+		step.Status = BulkFailed
+		step.Error = err.Error()
+		return step
+	}
+	step.Results = results
+
+	step.Status = BulkSkipped
+	for _, result := range results {
+		switch result.Status {
+		case BulkFailed:
+			step.Status = BulkFailed
+			return step
+		case BulkSucceeded:
+			step.Status = BulkSucceeded
+		}
+	}
+	return step
+}
+
+// projectServices maps the project's services to their container IDs and orders them
+// so that every service comes after its dependencies. Dependencies outside the project
+// are ignored and services in a dependency cycle are appended by name.
+func projectServices(project Project, deps map[string][]dependency) (map[string][]string, []string) {
+	services := make(map[string][]string)
+	for _, c := range project.Containers {
+		services[c.Compose.Service] = append(services[c.Compose.Service], c.ID)
+	}
+
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	order := make([]string, 0, len(names))
+	done := make(map[string]bool)
+	for len(order) < len(names) {
+		// Each round adds the services whose dependencies were added in earlier rounds
+		var ready []string
+		for _, name := range names {
+			if !done[name] && dependenciesDone(deps[name], services, done) {
+				ready = append(ready, name)
+			}
+		}
+		if len(ready) == 0 {
+			for _, name := range names {
+				if !done[name] {
+					ready = append(ready, name)
+				}
+			}
+		}
+		for _, name := range ready {
+			done[name] = true
+		}
+		order = append(order, ready...)
+	}
+	return services, order
+}
+
+func dependenciesDone(deps []dependency, services map[string][]string, done map[string]bool) bool {
+	for _, dep := range deps {
+		if _, inProject := services[dep.Service]; inProject && !done[dep.Service] {
+			return false
+		}
+	}
+	return true
+}
+
+// dependency is one depends_on entry of a Compose service
+type dependency struct {
+	Service   string
+	Condition string
+}
+
+// serviceDependencies reads the depends_on label of every container in the project
+func serviceDependencies(project Project) map[string][]dependency {
+	deps := make(map[string][]dependency)
+	for _, c := range project.Containers {
+		for _, entry := range strings.Split(c.Labels[ComposeDependsOnLabel], ",") {
+			parts := strings.Split(strings.TrimSpace(entry), ":")
+			dep := dependency{Service: parts[0], Condition: ConditionStarted}
+			if len(parts) > 1 && parts[1] != "" {
+				dep.Condition = parts[1]
+			}
+			if dep.Service != "" && dep.Service != c.Compose.Service {
+				deps[c.Compose.Service] = append(deps[c.Compose.Service], dep)
+			}
+		}
+	}
+	return deps
+}
+
+// failedDependency returns the first dependency that failed to start, if any
+func failedDependency(deps []dependency, failed map[string]bool) string {
+	for _, dep := range deps {
+		if failed[dep.Service] {
+			return dep.Service
+		}
+	}
+	return ""
+}
+
+// awaitDependencies waits for the containers of every dependency in the project to
+// meet its depends_on condition. Each dependency gets up to the transition timeout.
+func (s *ContainerService) awaitDependencies(ctx context.Context, deps []dependency, services map[string][]string) error {
+	for _, dep := range deps {
+		if dep.Condition == ConditionStarted {
+			continue
+		}
+		for _, id := range services[dep.Service] {
+			if err := s.awaitCondition(ctx, id, dep.Condition); err != nil {
+				return fmt.Errorf("dependency %s did not meet %s: %w", dep.Service, dep.Condition, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *ContainerService) awaitCondition(ctx context.Context, id, condition string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.transitionTimeout)
+	defer cancel()
+
+	return s.pollUntil(ctx, id, func(c docker.Container) (bool, error) {
+		switch condition {
+		case ConditionHealthy:
+			if c.State == "exited" || c.State == "dead" {
+				return false, fmt.Errorf("container %s", c.State)
+			}
+			health := parseHealth(c.Status)
+			if health == nil {
+				return false, errors.New("container has no healthcheck")
+			}
+			if health.Status == store.HealthUnhealthy {
+				return false, errors.New("container is unhealthy")
+			}
+			return health.Status == store.HealthHealthy, nil
+		case ConditionCompleted:
+			if c.State != "exited" && c.State != "dead" {
+				return false, nil
+			}
+			if code := parseExitCode(c.Status); code == nil || *code != 0 {
+				return false, errors.New("container did not exit successfully")
+			}
+			return true, nil
+		}
+		// Unknown conditions behave like service_started
+		return true, nil
+	})
+}
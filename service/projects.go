@@ -0,0 +1,129 @@
+package service
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/yarlson/duh/store"
+)
+
+// Docker Compose labels identifying a container's project, service and replica
+const (
+	ComposeProjectLabel = "com.docker.compose.project"
+	ComposeServiceLabel = "com.docker.compose.service"
+	ComposeNumberLabel  = "com.docker.compose.container-number"
+)
+
+// Aggregate project states
+const (
+	ProjectUp      = "up"      // every container is running
+	ProjectPartial = "partial" // some containers are running
+	ProjectDown    = "down"    // no container is running
+)
+
+// Project is a Docker Compose project with its containers and aggregate usage
+type Project struct {
+	Name       string                `json:"name"`
+	State      string                `json:"state"`
+	Running    int                   `json:"running"`
+	Total      int                   `json:"total"`
+	Stats      ProjectStats          `json:"stats"`
+	Containers []store.ContainerData `json:"containers"`
+}
+
+// ProjectStats sums the resource usage of a project's containers
+type ProjectStats struct {
+	CPU         float64 `json:"cpu"` // Sum of container CPU percentages
+	MemoryUsage uint64  `json:"memory_usage"`
+	MemoryLimit uint64  `json:"memory_limit"`
+}
+
+// composeInfo derives Compose metadata from container labels, or nil for containers
+// not created by Compose
+func composeInfo(labels map[string]string) *store.ComposeInfo {
+	project := labels[ComposeProjectLabel]
+	if project == "" {
+		return nil
+	}
+	info := &store.ComposeInfo{
+		Project: project,
+		Service: labels[ComposeServiceLabel],
+	}
+	if number, err := strconv.Atoi(labels[ComposeNumberLabel]); err == nil {
+		info.Number = number
+	}
+	return info
+}
+
+// Projects groups Compose containers by project, sorted by project name. Containers
+// within a project are ordered by service and replica number. Containers not created
+// by Compose are left out.
+func (s *ContainerService) Projects() []Project {
+	byName := make(map[string]*Project)
+	for _, c := range s.store.List() {
+		if c.Compose == nil {
+			continue
+		}
+		project, ok := byName[c.Compose.Project]
+		if !ok {
+			project = &Project{Name: c.Compose.Project}
+			byName[c.Compose.Project] = project
+		}
+		project.add(c)
+	}
+
+	projects := make([]Project, 0, len(byName))
+	for _, project := range byName {
+		project.finish()
+		projects = append(projects, *project)
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].Name < projects[j].Name
+	})
+	return projects
+}
+
+// Project returns a single Compose project by name
+func (s *ContainerService) Project(name string) (Project, bool) {
+	for _, project := range s.Projects() {
+		if project.Name == name {
+			return project, true
+		}
+	}
+	return Project{}, false
+}
+
+func (p *Project) add(c store.ContainerData) {
+	p.Containers = append(p.Containers, c)
+	p.Total++
+	if c.State == "running" {
+		p.Running++
+	}
+	if c.Stats != nil {
+		p.Stats.CPU += c.Stats.CPU.Usage
+		p.Stats.MemoryUsage += c.Stats.Memory.Usage
+		p.Stats.MemoryLimit += c.Stats.Memory.Limit
+	}
+}
+
+func (p *Project) finish() {
+	switch p.Running {
+	case p.Total:
+		p.State = ProjectUp
+	case 0:
+		p.State = ProjectDown
+	default:
+		p.State = ProjectPartial
+	}
+
+	sort.Slice(p.Containers, func(i, j int) bool {
+		a, b := p.Containers[i].Compose, p.Containers[j].Compose
+		if a.Service != b.Service {
+			return a.Service < b.Service
+		}
+		if a.Number != b.Number {
+			return a.Number < b.Number
+		}
+		return p.Containers[i].ID < p.Containers[j].ID
+	})
+}
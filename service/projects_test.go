@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yarlson/duh/docker"
+	"github.com/yarlson/duh/store"
+)
+
+func composeLabels(project, service, number string) map[string]string {
+	return map[string]string{
+		ComposeProjectLabel: project,
+		ComposeServiceLabel: service,
+		ComposeNumberLabel:  number,
+	}
+}
+
+func TestServiceProjects(t *testing.T) {
+	mockDocker := &DockerClientMock{
+		ListContainersFunc: func(ctx context.Context, all bool) ([]docker.Container, error) {
+			return []docker.Container{
+				{ID: "web2", State: "running", Labels: composeLabels("shop", "web", "2")},
+				{ID: "web1", State: "running", Labels: composeLabels("shop", "web", "1")},
+				{ID: "db", State: "exited", Labels: composeLabels("shop", "db", "1")},
+				{ID: "api", State: "running", Labels: composeLabels("blog", "api", "1")},
+				{ID: "worker", State: "exited", Labels: composeLabels("jobs", "worker", "1")},
+				{ID: "standalone", State: "running"},
+			}, nil
+		},
+	}
+
+	memoryStore := store.NewStore(time.Minute)
+	service := New(mockDocker, memoryStore)
+	if _, err := service.SyncContainers(context.Background()); err != nil {
+		t.Fatalf("SyncContainers failed: %v", err)
+	}
+
+	stats := &store.Stats{}
+	stats.CPU.Usage = 12.5
+	stats.Memory.Usage = 100
+	stats.Memory.Limit = 1000
+	memoryStore.UpdateStats("web1", stats)
+	memoryStore.UpdateStats("web2", stats)
+
+	web, _ := service.Get("web1")
+	if web.Compose == nil || web.Compose.Project != "shop" || web.Compose.Service != "web" || web.Compose.Number != 1 {
+		t.Errorf("Unexpected compose info %+v", web.Compose)
+	}
+
+	projects := service.Projects()
+	if len(projects) != 3 {
+		t.Fatalf("Expected 3 projects, got %d", len(projects))
+	}
+
+	wantStates := map[string]string{"blog": ProjectUp, "jobs": ProjectDown, "shop": ProjectPartial}
+	for i, name := range []string{"blog", "jobs", "shop"} {
+		if projects[i].Name != name || projects[i].State != wantStates[name] {
+			t.Errorf("Project %d: got %s (%s), want %s (%s)", i, projects[i].Name, projects[i].State, name, wantStates[name])
+		}
+	}
+
+	shop := projects[2]
+	if shop.Running != 2 || shop.Total != 3 {
+		t.Errorf("Expected 2 of 3 shop containers running, got %d of %d", shop.Running, shop.Total)
+	}
+	if shop.Stats.CPU != 25 || shop.Stats.MemoryUsage != 200 || shop.Stats.MemoryLimit != 2000 {
+		t.Errorf("Unexpected shop stats %+v", shop.Stats)
+	}
+	var ids []string
+	for _, c := range shop.Containers {
+		ids = append(ids, c.ID)
+	}
+	if len(ids) != 3 || ids[0] != "db" || ids[1] != "web1" || ids[2] != "web2" {
+		t.Errorf("Expected containers ordered by service and number, got %v", ids)
+	}
+
+	if _, ok := service.Project("missing"); ok {
+		t.Error("Expected unknown project not to be found")
+	}
+}
+
+func TestServiceProjectAction(t *testing.T) {
+	var calls []string
+	var mu sync.Mutex
+	record := func(call string) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, call)
+	}
+
+	mockDocker := &DockerClientMock{
+		StartContainerFunc: func(ctx context.Context, id string) error {
+			record("start " + id)
+			if id == "cache" {
+				return errors.New("port is already allocated")
+			}
+			return nil
+		},
+		StopContainerFunc: func(ctx context.Context, id string, opts docker.StopOptions) error {
+			record("stop " + id)
+			return nil
+		},
+		ListContainersFunc: func(ctx context.Context, all bool) ([]docker.Container, error) {
+			return nil, nil
+		},
+	}
+
+	memoryStore := store.NewStore(time.Minute)
+	add := func(id, service, dependsOn string) {
+		labels := composeLabels("shop", service, "1")
+		labels[ComposeDependsOnLabel] = dependsOn
+		memoryStore.Update(store.ContainerData{
+			ID:      id,
+			State:   "running",
+			Labels:  labels,
+			Compose: composeInfo(labels),
+		})
+	}
+	add("web", "web", "api:service_started:false")
+	add("api", "api", "db:service_healthy:false,cache:service_started:false")
+	add("db", "db", "")
+	add("cache", "cache", "")
+	add("worker", "worker", "db:service_started:false")
+
+	service := New(mockDocker, memoryStore)
+
+	steps, err := service.ProjectAction(context.Background(), "shop", "restart", docker.StopOptions{})
+	if err != nil {
+		t.Fatalf("ProjectAction failed: %v", err)
+	}
+
+	wantCalls := []string{
+		"stop web", "stop worker", "stop api", "stop db", "stop cache",
+		"start cache", "start db", "start worker",
+	}
+	if strings.Join(calls, ",") != strings.Join(wantCalls, ",") {
+		t.Errorf("Got calls %v, want %v", calls, wantCalls)
+	}
+
+	var got []string
+	for _, step := range steps {
+		got = append(got, step.Action+" "+step.Service+" "+step.Status)
+	}
+	wantSteps := []string{
+		"stop web succeeded", "stop worker succeeded", "stop api succeeded", "stop db succeeded", "stop cache succeeded",
+		"start cache failed", "start db succeeded", "start api skipped", "start worker succeeded", "start web skipped",
+	}
+	if strings.Join(got, ",") != strings.Join(wantSteps, ",") {
+		t.Errorf("Got steps %v, want %v", got, wantSteps)
+	}
+
+	if _, err := service.ProjectAction(context.Background(), "missing", "start", docker.StopOptions{}); !errors.Is(err, ErrProjectNotFound) {
+		t.Errorf("Expected ErrProjectNotFound, got %v", err)
+	}
+	if _, err := service.ProjectAction(context.Background(), "shop", "pause", docker.StopOptions{}); !errors.Is(err, ErrInvalidAction) {
+		t.Errorf("Expected ErrInvalidAction, got %v", err)
+	}
+}
+
+func TestServiceProjectActionAwaitsDependencyConditions(t *testing.T) {
+	var calls []string
+	var mu sync.Mutex
+	record := func(call string) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, call)
+	}
+
+	// db reports healthy on the third inspect, migrate has already exited and
+	// cache never gets past its health: starting grace period
+	inspects := make(map[string]int)
+	mockDocker := &DockerClientMock{
+		StartContainerFunc: func(ctx context.Context, id string) error {
+			record("start " + id)
+			return nil
+		},
+		GetContainerFunc: func(ctx context.Context, id string) (*docker.Container, error) {
+			mu.Lock()
+			inspects[id]++
+			n := inspects[id]
+			mu.Unlock()
+
+			c := &docker.Container{ID: id, State: "running", Status: "Up 1 second (health: starting)"}
+			switch {
+			case id == "db" && n >= 3:
+				c.Status = "Up 3 seconds (healthy)"
+			case id == "migrate":
+				c.State, c.Status = "exited", "Exited (0) 1 second ago"
+			}
+			return c, nil
+		},
+		ListContainersFunc: func(ctx context.Context, all bool) ([]docker.Container, error) {
+			return nil, nil
+		},
+	}
+
+	memoryStore := store.NewStore(time.Minute)
+	add := func(id, service, dependsOn string) {
+		labels := composeLabels("shop", service, "1")
+		labels[ComposeDependsOnLabel] = dependsOn
+		memoryStore.Update(store.ContainerData{
+			ID:      id,
+			State:   "exited",
+			Labels:  labels,
+			Compose: composeInfo(labels),
+		})
+	}
+	add("db", "db", "")
+	add("migrate", "migrate", "db:service_healthy:false")
+	add("api", "api", "db:service_healthy:false,migrate:service_completed_successfully:false")
+	add("cache", "cache", "")
+	add("worker", "worker", "cache:service_healthy:false")
+
+	service := New(mockDocker, memoryStore, WithTransitionTimeout(time.Second))
+
+	steps, err := service.ProjectAction(context.Background(), "shop", "start", docker.StopOptions{})
+	if err != nil {
+		t.Fatalf("ProjectAction failed: %v", err)
+	}
+
+	// migrate and api start only after db reports healthy; worker gives up on cache
+	wantCalls := []string{"start cache", "start db", "start migrate", "start api"}
+	if strings.Join(calls, ",") != strings.Join(wantCalls, ",") {
+		t.Errorf("Got calls %v, want %v", calls, wantCalls)
+	}
+	if inspects["db"] < 3 {
+		t.Errorf("Expected db to be polled until healthy, got %d inspects", inspects["db"])
+	}
+
+	var got []string
+	for _, step := range steps {
+		got = append(got, step.Service+" "+step.Status)
+	}
+	wantSteps := []string{"cache succeeded", "db succeeded", "migrate succeeded", "worker skipped", "api succeeded"}
+	if strings.Join(got, ",") != strings.Join(wantSteps, ",") {
+		t.Errorf("Got steps %v, want %v", got, wantSteps)
+	}
+	for _, step := range steps {
+		if step.Service == "worker" && !strings.Contains(step.Error, "dependency cache did not meet service_healthy") {
+			t.Errorf("Unexpected worker error %q", step.Error)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// QueryError describes why a filter query could not be parsed
+type QueryError struct {
+	Pos     int // Byte offset in the query
+	Message string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("invalid query at position %d: %s", e.Pos, e.Message)
+}
+
+// ParseQuery parses a filter expression such as
+//
+//	state:running label:team=payments -image:redis
+//
+// Terms are field:value pairs, with fields state, label, image, name, project and id,
+// or bare words that search names, image and ID. Adjacent terms must all match;
+// OR matches either side, a leading - or NOT negates a term and parentheses group.
+// Values containing spaces can be quoted; a quoted bare word is always searched as
+// text. An empty query matches everything.
+func ParseQuery(query string) (Filter, error) {
+	tokens, err := tokenizeQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{tokens: tokens, end: len(query)}
+	if len(tokens) == 0 {
+		return MatchAll(), nil
+	}
+
+	filter, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok, ok := p.peek(); ok {
+		return nil, &QueryError{Pos: tok.pos, Message: fmt.Sprintf("unexpected %q", tok.text)}
+	}
+	return filter, nil
+}
+
+type queryTokenKind int
+
+const (
+	tokenTerm queryTokenKind = iota
+	tokenOr
+	tokenNot
+	tokenOpen
+	tokenClose
+)
+
+type queryToken struct {
+	kind   queryTokenKind
+	text   string
+	pos    int
+	quoted bool // the term started with a quote and is searched as text
+}
+
+func tokenizeQuery(query string) ([]queryToken, error) {
+	var tokens []queryToken
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case c == '(':
+			tokens = append(tokens, queryToken{kind: tokenOpen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, queryToken{kind: tokenClose, text: ")", pos: i})
+			i++
+		case c == '-':
+			tokens = append(tokens, queryToken{kind: tokenNot, text: "-", pos: i})
+			i++
+		default:
+			start := i
+			var word strings.Builder
+			quoted := false
+			for ; i < len(query); i++ {
+				c := query[i]
+				if c == '"' {
+					quoted = !quoted
+					continue
+				}
+				if !quoted && (unicode.IsSpace(rune(c)) || c == '(' || c == ')') {
+					break
+				}
+				word.WriteByte(c)
+			}
+			if quoted {
+				return nil, &QueryError{Pos: start, Message: "unterminated quote"}
+			}
+
+			tok := queryToken{kind: tokenTerm, text: word.String(), pos: start, quoted: query[start] == '"'}
+			switch query[start:i] {
+			case "OR":
+				tok.kind = tokenOr
+			case "AND":
+				continue // adjacent terms are already combined with AND
+			case "NOT":
+				tok.kind = tokenNot
+			}
+			tokens = append(tokens, tok)
+		}
+	}
+	return tokens, nil
+}
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+	end    int
+}
+
+func (p *queryParser) peek() (queryToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return queryToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+// parseOr parses terms joined by OR
+func (p *queryParser) parseOr() (Filter, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	filters := []Filter{first}
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind != tokenOr {
+			break
+		}
+		p.pos++
+		next, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, next)
+	}
+	if len(filters) == 1 {
+		return first, nil
+	}
+	return matchAny(filters...), nil
+}
+
+// parseAnd parses a sequence of adjacent terms
+func (p *queryParser) parseAnd() (Filter, error) {
+	var filters []Filter
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind == tokenOr || tok.kind == tokenClose {
+			break
+		}
+		filter, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	if len(filters) == 0 {
+		return nil, p.errExpected()
+	}
+	if len(filters) == 1 {
+		return filters[0], nil
+	}
+	return MatchAll(filters...), nil
+}
+
+func (p *queryParser) parseUnary() (Filter, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return nil, p.errExpected()
+	}
+
+	switch tok.kind {
+	case tokenNot:
+		p.pos++
+		filter, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return not(filter), nil
+	case tokenOpen:
+		p.pos++
+		filter, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing, ok := p.peek(); !ok || closing.kind != tokenClose {
+			return nil, &QueryError{Pos: tok.pos, Message: "unclosed parenthesis"}
+		}
+		p.pos++
+		return filter, nil
+	case tokenTerm:
+		p.pos++
+		return parseQueryTerm(tok)
+	default:
+		return nil, p.errExpected()
+	}
+}
+
+func (p *queryParser) errExpected() error {
+	if tok, ok := p.peek(); ok {
+		return &QueryError{Pos: tok.pos, Message: fmt.Sprintf("expected a term, got %q", tok.text)}
+	}
+	return &QueryError{Pos: p.end, Message: "expected a term"}
+}
+
+func parseQueryTerm(tok queryToken) (Filter, error) {
+	field, value, hasField := strings.Cut(tok.text, ":")
+	if !hasField || tok.quoted {
+		return MatchText(tok.text), nil
+	}
+	if value == "" {
+		return nil, &QueryError{Pos: tok.pos, Message: fmt.Sprintf("missing value for %s", field)}
+	}
+
+	switch strings.ToLower(field) {
+	case "state":
+		return MatchState(value), nil
+	case "label":
+		return MatchLabel(value), nil
+	case "image":
+		return MatchImage(value), nil
+	case "name":
+		return MatchName(value), nil
+	case "project":
+		return MatchProject(value), nil
+	case "id":
+		return MatchID(value), nil
+	default:
+		return nil, &QueryError{Pos: tok.pos, Message: fmt.Sprintf("unknown field %q", field)}
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yarlson/duh/store"
+)
+
+func TestParseQuery(t *testing.T) {
+	containers := []store.ContainerData{
+		{ID: "aaa111", Names: []string{"/payments-api"}, Image: "payments:1.2", State: "running", Labels: map[string]string{"team": "payments"}},
+		{ID: "bbb222", Names: []string{"/payments-cache"}, Image: "redis:7", State: "running", Labels: map[string]string{"team": "payments"}},
+		{ID: "ccc333", Names: []string{"/search"}, Image: "elasticsearch:8", State: "exited", Labels: map[string]string{"team": "search"},
+			Compose: &store.ComposeInfo{Project: "search", Service: "search"}},
+	}
+
+	testCases := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"aaa111", "bbb222", "ccc333"}},
+		{"state:running label:team=payments -image:redis", []string{"aaa111"}},
+		{"payments", []string{"aaa111", "bbb222"}},
+		{"PAYMENTS AND NOT cache", []string{"aaa111"}},
+		{"image:redis OR project:search", []string{"bbb222", "ccc333"}},
+		{"-(state:running label:team)", []string{"ccc333"}},
+		{"name:payments-* id:bbb", []string{"bbb222"}},
+		{`label:"team=payments" "redis:7"`, []string{"bbb222"}},
+		{"label:team=search OR (state:running -name:*cache)", []string{"aaa111", "ccc333"}},
+	}
+
+	for _, tc := range testCases {
+		filter, err := ParseQuery(tc.query)
+		if err != nil {
+			t.Errorf("ParseQuery(%q) failed: %v", tc.query, err)
+			continue
+		}
+		var got []string
+		for _, c := range FilterContainers(containers, filter) {
+			got = append(got, c.ID)
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("ParseQuery(%q) matched %v, want %v", tc.query, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("ParseQuery(%q) matched %v, want %v", tc.query, got, tc.want)
+				break
+			}
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	testCases := []struct {
+		query string
+		pos   int
+	}{
+		{"colour:red", 0},
+		{"state:", 0},
+		{"state:running OR", 16},
+		{"(state:running", 0},
+		{"state:running)", 13},
+		{`name:"payments`, 0},
+		{"-", 1},
+	}
+
+	for _, tc := range testCases {
+		_, err := ParseQuery(tc.query)
+		var queryErr *QueryError
+		if !errors.As(err, &queryErr) {
+			t.Errorf("ParseQuery(%q): expected QueryError, got %v", tc.query, err)
+			continue
+		}
+		if queryErr.Pos != tc.pos {
+			t.Errorf("ParseQuery(%q): error at position %d, want %d (%v)", tc.query, queryErr.Pos, tc.pos, err)
+		}
+	}
+}
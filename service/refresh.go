@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/yarlson/duh/docker"
+)
+
+const (
+	refreshInitialBackoff = 100 * time.Millisecond
+	refreshMaxBackoff     = 2 * time.Second
+)
+
+// refreshCall is a poll loop shared by all callers refreshing the same container
+type refreshCall struct {
+	done   chan struct{}
+	target string // guarded by ContainerService.refreshMu
+	err    error
+}
+
+// WithActionRefresh makes StartContainer and StopContainer refresh the affected
+// container in the background instead of waiting for the next Sync
+func WithActionRefresh() Option {
+	return func(s *ContainerService) {
+		s.actionRefresh = true
+	}
+}
+
+// RefreshContainer polls Docker for a single container with a short exponential backoff
+// until it reaches the target state, disappears, or the transition timeout elapses.
+// Concurrent refreshes of the same container share one poll loop; a later call changes
+// the state that loop waits for, so a burst of actions results in a single poller.
+func (s *ContainerService) RefreshContainer(ctx context.Context, id, target string) error {
+	s.refreshMu.Lock()
+	call, inFlight := s.refreshes[id]
+	if inFlight {
+		call.target = target
+	} else {
+		call = &refreshCall{done: make(chan struct{}), target: target}
+		s.refreshes[id] = call
+		go s.pollContainer(id, call)
+	}
+	s.refreshMu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// pollContainer runs the poll loop for a refreshCall. It is detached from the
+// callers' contexts so that one caller going away does not cancel the others.
+func (s *ContainerService) pollContainer(id string, call *refreshCall) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.transitionTimeout)
+	defer cancel()
+
+	defer func() {
+		s.refreshMu.Lock()
+		delete(s.refreshes, id)
+		s.refreshMu.Unlock()
+		close(call.done)
+	}()
+
+	err := s.pollUntil(ctx, id, func(c docker.Container) (bool, error) {
+		s.refreshMu.Lock()
+		defer s.refreshMu.Unlock()
+		return c.State == call.target, nil
+	})
+	// Leave the transition in place for Sync to expire on timeout
+	if !errors.Is(err, docker.ErrNotFound) {
+		call.err = err
+	}
+}
+
+// pollUntil polls Docker for a single container with a short exponential backoff
+// until check reports it done, and then stores the container. A container that
+// disappears is removed from the store and reported as docker.ErrNotFound.
+func (s *ContainerService) pollUntil(ctx context.Context, id string, check func(docker.Container) (bool, error)) error {
+	backoff := refreshInitialBackoff
+	for {
+		c, err := s.client.GetContainer(ctx, id)
+		switch {
+		case errors.Is(err, docker.ErrNotFound):
+			s.store.Remove(id)
+			return err
+		case err != nil:
+			s.metrics.dockerError(OpInspect, err)
+		default:
+			done, err := check(*c)
+			if done || err != nil {
+				s.store.Update(containerData(*c))
+				return err
+			}
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		backoff *= 2
+		if backoff > refreshMaxBackoff {
+			backoff = refreshMaxBackoff
+		}
+	}
+}
+
+// refreshAfterAction starts a background refresh when WithActionRefresh is enabled
+func (s *ContainerService) refreshAfterAction(id, target string) {
+	if !s.actionRefresh {
+		return
+	}
+	go func() {
+		_ = s.RefreshContainer(context.Background(), id, target)
+	}()
+}
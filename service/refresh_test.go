@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yarlson/duh/docker"
+	"github.com/yarlson/duh/store"
+)
+
+func TestRefreshContainerCoalesces(t *testing.T) {
+	var polls atomic.Int32
+	mockDocker := &DockerClientMock{
+		GetContainerFunc: func(ctx context.Context, id string) (*docker.Container, error) {
+			// Report the container as still exited for the first few polls
+			state := "exited"
+			if polls.Add(1) > 2 {
+				state = "running"
+			}
+			return &docker.Container{ID: id, State: state, Status: "Up 1 second"}, nil
+		},
+	}
+
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(store.ContainerData{ID: "web", State: store.StateStarting})
+	service := New(mockDocker, memoryStore)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := service.RefreshContainer(context.Background(), "web", "running"); err != nil {
+				t.Errorf("RefreshContainer failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := polls.Load(); got != 3 {
+		t.Errorf("Expected concurrent refreshes to share 3 polls, got %d", got)
+	}
+
+	container, _ := service.Get("web")
+	if container.State != "running" {
+		t.Errorf("Expected state running after refresh, got %s", container.State)
+	}
+}
+
+func TestRefreshContainerRemoved(t *testing.T) {
+	mockDocker := &DockerClientMock{
+		GetContainerFunc: func(ctx context.Context, id string) (*docker.Container, error) {
+			return nil, docker.ErrNotFound
+		},
+	}
+
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(store.ContainerData{ID: "web", State: store.StateStopping})
+	service := New(mockDocker, memoryStore)
+
+	if err := service.RefreshContainer(context.Background(), "web", "exited"); err != nil {
+		t.Fatalf("RefreshContainer failed: %v", err)
+	}
+	if _, exists := service.Get("web"); exists {
+		t.Error("Expected container missing from Docker to be removed")
+	}
+}
+
+func TestRefreshContainerTimeout(t *testing.T) {
+	mockDocker := &DockerClientMock{
+		GetContainerFunc: func(ctx context.Context, id string) (*docker.Container, error) {
+			return &docker.Container{ID: id, State: "exited"}, nil
+		},
+	}
+
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(store.ContainerData{ID: "web", State: store.StateStarting})
+	service := New(mockDocker, memoryStore, WithTransitionTimeout(50*time.Millisecond))
+
+	if err := service.RefreshContainer(context.Background(), "web", "running"); err == nil {
+		t.Error("Expected refresh to time out")
+	}
+	if container, _ := service.Get("web"); container.State != store.StateStarting {
+		t.Errorf("Expected transition to be left for Sync to expire, got %s", container.State)
+	}
+}
+
+func TestStartContainerActionRefresh(t *testing.T) {
+	mockDocker := &DockerClientMock{
+		StartContainerFunc: func(ctx context.Context, id string) error {
+			return nil
+		},
+		ListContainersFunc: func(ctx context.Context, all bool) ([]docker.Container, error) {
+			return []docker.Container{{ID: "web", State: "exited"}}, nil
+		},
+		GetContainerFunc: func(ctx context.Context, id string) (*docker.Container, error) {
+			return &docker.Container{ID: id, State: "running", Status: "Up Less than a second"}, nil
+		},
+	}
+
+	service := New(mockDocker, store.NewStore(time.Minute), WithActionRefresh())
+	if err := service.StartContainer(context.Background(), "web"); err != nil {
+		t.Fatalf("StartContainer failed: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if container, _ := service.Get("web"); container.State == "running" {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("Container not refreshed to running after start")
+}
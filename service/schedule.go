@@ -0,0 +1,450 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/yarlson/duh/logger"
+)
+
+var (
+	// ErrScheduleNotFound is returned for unknown schedule IDs
+	ErrScheduleNotFound = errors.New("schedule not found")
+	// ErrInvalidSchedule wraps validation failures of a schedule definition
+	ErrInvalidSchedule = errors.New("invalid schedule")
+)
+
+const (
+	// scheduleGrace is how late a run may start before it counts as missed
+	scheduleGrace = time.Minute
+	// schedulePoll bounds how long the scheduler sleeps, so that clock changes and
+	// suspends are noticed
+	schedulePoll = time.Minute
+	// maxMissedRuns bounds the occurrences walked to find the latest missed one
+	maxMissedRuns = 100_000
+)
+
+// Schedule runs a start or stop action on a cron schedule. Targets are the listed IDs
+// plus every container matching the selector, as in BulkRequest.
+type Schedule struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name,omitempty"`
+	Cron     string    `json:"cron"`
+	Timezone string    `json:"timezone,omitempty"` // IANA zone of the cron expression, local time when empty
+	Action   string    `json:"action"`
+	IDs      []string  `json:"ids,omitempty"`
+	Selector *Selector `json:"selector,omitempty"`
+	Disabled bool      `json:"disabled,omitempty"`
+	// SkipMissed drops runs missed while duh was not running instead of catching up
+	// with the most recent one
+	SkipMissed bool `json:"skip_missed,omitempty"`
+
+	Created time.Time    `json:"created"`
+	Updated time.Time    `json:"updated"`
+	NextRun *time.Time   `json:"next_run,omitempty"` // Computed, not saved
+	LastRun *ScheduleRun `json:"last_run,omitempty"`
+}
+
+// ScheduleRun is the outcome of one scheduled occurrence
+type ScheduleRun struct {
+	Scheduled time.Time    `json:"scheduled"` // The occurrence the run belongs to
+	Started   time.Time    `json:"started"`
+	Finished  *time.Time   `json:"finished,omitempty"`
+	Missed    bool         `json:"missed,omitempty"`  // Started more than a minute late, e.g. after a restart
+	Skipped   bool         `json:"skipped,omitempty"` // Missed and not caught up because of SkipMissed
+	Results   []BulkResult `json:"results,omitempty"`
+	Error     string       `json:"error,omitempty"`
+}
+
+type scheduleEntry struct {
+	Schedule
+	cron    *Cron
+	loc     *time.Location
+	running bool
+}
+
+// Scheduler runs schedules and persists them to a JSON file
+type Scheduler struct {
+	service *ContainerService
+	path    string
+	now     func() time.Time
+
+	mu      sync.Mutex
+	entries []*scheduleEntry
+	wake    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// SchedulerOption configures a Scheduler
+type SchedulerOption func(*Scheduler)
+
+// WithSchedulerClock replaces time.Now, for tests
+func WithSchedulerClock(now func() time.Time) SchedulerOption {
+	return func(sch *Scheduler) {
+		sch.now = now
+	}
+}
+
+// NewScheduler creates a scheduler for the service, loading schedules saved at path.
+// A missing file starts with no schedules; an empty path disables persistence.
+func NewScheduler(service *ContainerService, path string, opts ...SchedulerOption) (*Scheduler, error) {
+	sch := &Scheduler{
+		service: service,
+		path:    path,
+		now:     time.Now,
+		wake:    make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(sch)
+	}
+
+	if path == "" {
+		return sch, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return sch, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read schedules: %w", err)
+	}
+	var schedules []Schedule
+	if err := json.Unmarshal(data, &schedules); err != nil {
+		return nil, fmt.Errorf("parse schedules %s: %w", path, err)
+	}
+	for _, schedule := range schedules {
+		entry, err := newScheduleEntry(schedule)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %s: %w", schedule.ID, err)
+		}
+		sch.entries = append(sch.entries, entry)
+	}
+	return sch, nil
+}
+
+// newScheduleEntry validates a schedule and compiles its cron expression
+func newScheduleEntry(schedule Schedule) (*scheduleEntry, error) {
+	if schedule.Action != "start" && schedule.Action != "stop" {
+		return nil, fmt.Errorf("%w: action must be start or stop", ErrInvalidSchedule)
+	}
+	if len(schedule.IDs) == 0 && (schedule.Selector == nil || schedule.Selector.Empty()) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchedule, ErrNoTargets)
+	}
+	cron, err := ParseCron(schedule.Cron)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	loc := time.Local
+	if schedule.Timezone != "" {
+		if loc, err = time.LoadLocation(schedule.Timezone); err != nil {
+			return nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidSchedule, schedule.Timezone)
+		}
+	}
+	if cron.Next(time.Now().In(loc)).IsZero() {
+		return nil, fmt.Errorf("%w: cron expression %q never matches", ErrInvalidSchedule, schedule.Cron)
+	}
+	schedule.NextRun = nil
+	return &scheduleEntry{Schedule: schedule, cron: cron, loc: loc}, nil
+}
+
+// Schedules returns all schedules in creation order
+func (sch *Scheduler) Schedules() []Schedule {
+	sch.mu.Lock()
+	defer sch.mu.Unlock()
+
+	now := sch.now()
+	schedules := make([]Schedule, 0, len(sch.entries))
+	for _, entry := range sch.entries {
+		schedules = append(schedules, entry.view(now))
+	}
+	return schedules
+}
+
+// Schedule returns a schedule by ID
+func (sch *Scheduler) Schedule(id string) (Schedule, error) {
+	sch.mu.Lock()
+	defer sch.mu.Unlock()
+
+	entry, _ := sch.find(id)
+	if entry == nil {
+		return Schedule{}, ErrScheduleNotFound
+	}
+	return entry.view(sch.now()), nil
+}
+
+// CreateSchedule validates and saves a new schedule. Occurrences before its creation
+// are never run.
+func (sch *Scheduler) CreateSchedule(schedule Schedule) (Schedule, error) {
+	now := sch.now()
+	schedule.ID = newScheduleID()
+	schedule.Created, schedule.Updated = now, now
+	schedule.LastRun = nil
+	entry, err := newScheduleEntry(schedule)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	sch.mu.Lock()
+	defer sch.mu.Unlock()
+	sch.entries = append(sch.entries, entry)
+	if err := sch.save(); err != nil {
+		sch.entries = sch.entries[:len(sch.entries)-1]
+		return Schedule{}, err
+	}
+	sch.notify()
+	return entry.view(sch.now()), nil
+}
+
+// UpdateSchedule replaces the definition of a schedule, keeping its history.
+// Occurrences before the update are never run.
+func (sch *Scheduler) UpdateSchedule(id string, schedule Schedule) (Schedule, error) {
+	sch.mu.Lock()
+	defer sch.mu.Unlock()
+
+	existing, i := sch.find(id)
+	if existing == nil {
+		return Schedule{}, ErrScheduleNotFound
+	}
+	schedule.ID = id
+	schedule.Created = existing.Created
+	schedule.Updated = sch.now()
+	schedule.LastRun = existing.LastRun
+	entry, err := newScheduleEntry(schedule)
+	if err != nil {
+		return Schedule{}, err
+	}
+	entry.running = existing.running
+
+	sch.entries[i] = entry
+	if err := sch.save(); err != nil {
+		sch.entries[i] = existing
+		return Schedule{}, err
+	}
+	sch.notify()
+	return entry.view(sch.now()), nil
+}
+
+// DeleteSchedule removes a schedule. A run in progress is not interrupted.
+func (sch *Scheduler) DeleteSchedule(id string) error {
+	sch.mu.Lock()
+	defer sch.mu.Unlock()
+
+	entry, i := sch.find(id)
+	if entry == nil {
+		return ErrScheduleNotFound
+	}
+	sch.entries = append(sch.entries[:i:i], sch.entries[i+1:]...)
+	if err := sch.save(); err != nil {
+		sch.entries = append(sch.entries[:i:i], append([]*scheduleEntry{entry}, sch.entries[i:]...)...)
+		return err
+	}
+	sch.notify()
+	return nil
+}
+
+// Run executes due schedules until ctx is cancelled. Occurrences missed while duh was
+// not running are caught up once on start, unless the schedule skips missed runs.
+func (sch *Scheduler) Run(ctx context.Context) {
+	defer sch.wg.Wait()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		case <-sch.wake:
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+		}
+
+		now := sch.now()
+		wait := schedulePoll
+		if next, ok := sch.tick(ctx, now); ok && next.Sub(now) < wait {
+			wait = next.Sub(now)
+		}
+		timer.Reset(wait)
+	}
+}
+
+// tick starts every schedule with an occurrence due at now and returns the earliest
+// upcoming run
+func (sch *Scheduler) tick(ctx context.Context, now time.Time) (time.Time, bool) {
+	sch.mu.Lock()
+	defer sch.mu.Unlock()
+
+	changed := false
+	var earliest time.Time
+	for _, entry := range sch.entries {
+		if entry.Disabled {
+			continue
+		}
+
+		if due, ok := entry.due(now); ok && !entry.running {
+			run := &ScheduleRun{Scheduled: due, Started: now, Missed: now.Sub(due) > scheduleGrace}
+			if run.Missed && entry.SkipMissed {
+				run.Skipped = true
+			} else {
+				entry.running = true
+				sch.wg.Add(1)
+				go sch.run(ctx, entry.ID, entry.request(), run)
+			}
+			entry.LastRun = run
+			changed = true
+		}
+
+		next := entry.next(now)
+		if earliest.IsZero() || next.Before(earliest) {
+			earliest = next
+		}
+	}
+
+	if changed {
+		if err := sch.save(); err != nil {
+			logger.New().Warn("Saving schedules failed: %v", err)
+		}
+	}
+	return earliest, !earliest.IsZero()
+}
+
+// run executes one occurrence of a schedule and records its outcome
+func (sch *Scheduler) run(ctx context.Context, id string, req BulkRequest, run *ScheduleRun) {
+	defer sch.wg.Done()
+
+	results, err := sch.service.BulkAction(ctx, req)
+	finished := sch.now()
+
+	outcome := *run
+	outcome.Finished = &finished
+	outcome.Results = results
+	if err != nil {
+		outcome.Error = err.Error()
+		logger.New().Warn("Schedule %s failed: %v", id, err)
+	}
+
+	sch.mu.Lock()
+	defer sch.mu.Unlock()
+	entry, _ := sch.find(id)
+	if entry == nil {
+		return // deleted while running
+	}
+	entry.running = false
+	if entry.LastRun != nil && entry.LastRun.Scheduled.Equal(run.Scheduled) {
+		entry.LastRun = &outcome
+	}
+	if err := sch.save(); err != nil {
+		logger.New().Warn("Saving schedules failed: %v", err)
+	}
+}
+
+// reference is the time after which occurrences of the schedule are still pending
+func (entry *scheduleEntry) reference(now time.Time) time.Time {
+	ref := entry.Updated
+	if entry.LastRun != nil && entry.LastRun.Scheduled.After(ref) {
+		ref = entry.LastRun.Scheduled
+	}
+	if ref.After(now) {
+		return now
+	}
+	return ref
+}
+
+// next returns the first occurrence after the pending ones
+func (entry *scheduleEntry) next(now time.Time) time.Time {
+	return entry.cron.Next(entry.reference(now).In(entry.loc))
+}
+
+// due returns the most recent pending occurrence at or before now
+func (entry *scheduleEntry) due(now time.Time) (time.Time, bool) {
+	var due time.Time
+	next := entry.next(now)
+	for i := 0; i < maxMissedRuns && !next.IsZero() && !next.After(now); i++ {
+		due = next
+		next = entry.cron.Next(next)
+	}
+	return due, !due.IsZero()
+}
+
+func (entry *scheduleEntry) request() BulkRequest {
+	return BulkRequest{Action: entry.Action, IDs: entry.IDs, Selector: entry.Selector}
+}
+
+// view returns a copy of the schedule safe to hand out without the lock, with its
+// next run as of now
+func (entry *scheduleEntry) view(now time.Time) Schedule {
+	schedule := entry.Schedule
+	if !entry.Disabled {
+		next := entry.next(now)
+		schedule.NextRun = &next
+	}
+	schedule.IDs = append([]string(nil), entry.IDs...)
+	if entry.Selector != nil {
+		selector := *entry.Selector
+		schedule.Selector = &selector
+	}
+	return schedule
+}
+
+// find returns the entry with the ID and its index. It is called with sch.mu held.
+func (sch *Scheduler) find(id string) (*scheduleEntry, int) {
+	for i, entry := range sch.entries {
+		if entry.ID == id {
+			return entry, i
+		}
+	}
+	return nil, -1
+}
+
+// notify wakes Run to pick up changed schedules
+func (sch *Scheduler) notify() {
+	select {
+	case sch.wake <- struct{}{}:
+	default:
+	}
+}
+
+// save writes all schedules to the file atomically. It is called with sch.mu held.
+func (sch *Scheduler) save() error {
+	if sch.path == "" {
+		return nil
+	}
+	schedules := make([]Schedule, 0, len(sch.entries))
+	for _, entry := range sch.entries {
+		schedules = append(schedules, entry.Schedule)
+	}
+	data, err := json.MarshalIndent(schedules, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode schedules: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(sch.path), 0o755); err != nil {
+		return fmt.Errorf("save schedules: %w", err)
+	}
+	tmp := sch.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("save schedules: %w", err)
+	}
+	if err := os.Rename(tmp, sch.path); err != nil {
+		return fmt.Errorf("save schedules: %w", err)
+	}
+	return nil
+}
+
+func newScheduleID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yarlson/duh/docker"
+	"github.com/yarlson/duh/store"
+)
+
+func TestSchedulerValidation(t *testing.T) {
+	sch, err := NewScheduler(New(&DockerClientMock{}, store.NewStore(time.Minute)), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, schedule := range []Schedule{
+		{Cron: "0 19 * * *", Action: "restart", IDs: []string{"1"}},
+		{Cron: "0 19 * * *", Action: "stop"},
+		{Cron: "0 25 * * *", Action: "stop", IDs: []string{"1"}},
+		{Cron: "0 0 30 2 *", Action: "stop", IDs: []string{"1"}},
+		{Cron: "0 19 * * *", Action: "stop", IDs: []string{"1"}, Timezone: "Mars/Olympus"},
+	} {
+		if _, err := sch.CreateSchedule(schedule); !errors.Is(err, ErrInvalidSchedule) {
+			t.Errorf("CreateSchedule(%+v) error = %v, want ErrInvalidSchedule", schedule, err)
+		}
+	}
+	if _, err := sch.Schedule("missing"); !errors.Is(err, ErrScheduleNotFound) {
+		t.Errorf("Expected ErrScheduleNotFound, got %v", err)
+	}
+}
+
+func TestSchedulerRunsAndCatchesUp(t *testing.T) {
+	var mu sync.Mutex
+	var stopped []string
+	mockDocker := &DockerClientMock{
+		StopContainerFunc: func(ctx context.Context, id string, opts docker.StopOptions) error {
+			mu.Lock()
+			defer mu.Unlock()
+			stopped = append(stopped, id)
+			return nil
+		},
+	}
+	memoryStore := store.NewStore(time.Hour)
+	memoryStore.Update(store.ContainerData{ID: "heavy", Names: []string{"/heavy"}, State: "running", Labels: map[string]string{"size": "heavy"}})
+	memoryStore.Update(store.ContainerData{ID: "light", Names: []string{"/light"}, State: "running"})
+	service := New(mockDocker, memoryStore)
+
+	// Friday afternoon
+	now := time.Date(2024, 3, 15, 18, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	path := filepath.Join(t.TempDir(), "schedules.json")
+
+	sch, err := NewScheduler(service, path, WithSchedulerClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, err := sch.CreateSchedule(Schedule{
+		Name:     "evening",
+		Cron:     "0 19 * * mon-fri",
+		Timezone: "UTC",
+		Action:   "stop",
+		Selector: &Selector{Label: "size=heavy"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 3, 15, 19, 0, 0, 0, time.UTC); created.NextRun == nil || !created.NextRun.Equal(want) {
+		t.Fatalf("Expected next run at %v, got %v", want, created.NextRun)
+	}
+	skipping, err := sch.CreateSchedule(Schedule{
+		Cron:       "0 19 * * mon-fri",
+		Timezone:   "UTC",
+		Action:     "stop",
+		IDs:        []string{"light"},
+		SkipMissed: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing is due before 19:00
+	sch.tick(context.Background(), now)
+	sch.wg.Wait()
+	if len(stopped) != 0 {
+		t.Fatalf("Expected no runs yet, got %v", stopped)
+	}
+
+	// duh was not running over the weekend and restarts on Tuesday morning, after
+	// missing the runs of Friday and Monday
+	now = time.Date(2024, 3, 19, 8, 0, 0, 0, time.UTC)
+	sch, err = NewScheduler(service, path, WithSchedulerClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, ok := sch.tick(context.Background(), now)
+	sch.wg.Wait()
+
+	if len(stopped) != 1 || stopped[0] != "heavy" {
+		t.Fatalf("Expected a single catch-up stop of heavy, got %v", stopped)
+	}
+	if want := time.Date(2024, 3, 19, 19, 0, 0, 0, time.UTC); !ok || !next.Equal(want) {
+		t.Errorf("Expected the next run at %v, got %v", want, next)
+	}
+
+	schedule, err := sch.Schedule(created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := schedule.LastRun
+	if run == nil || !run.Missed || run.Skipped || run.Finished == nil || len(run.Results) != 1 {
+		t.Fatalf("Unexpected last run %+v", run)
+	}
+	if want := time.Date(2024, 3, 18, 19, 0, 0, 0, time.UTC); !run.Scheduled.Equal(want) {
+		t.Errorf("Expected the Monday run to be caught up, got %v", run.Scheduled)
+	}
+
+	schedule, err = sch.Schedule(skipping.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schedule.LastRun == nil || !schedule.LastRun.Skipped {
+		t.Errorf("Expected the missed run to be skipped, got %+v", schedule.LastRun)
+	}
+
+	// Runs are saved: a restart right away has nothing to catch up
+	sch, err = NewScheduler(service, path, WithSchedulerClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sch.tick(context.Background(), now)
+	sch.wg.Wait()
+	if len(stopped) != 1 {
+		t.Errorf("Expected no further runs, got %v", stopped)
+	}
+
+	if err := sch.DeleteSchedule(created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if len(sch.Schedules()) != 1 {
+		t.Errorf("Expected one schedule left, got %+v", sch.Schedules())
+	}
+}
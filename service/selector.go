@@ -0,0 +1,73 @@
+package service
+
+import (
+	"path"
+	"strings"
+
+	"github.com/yarlson/duh/store"
+)
+
+// Selector picks containers by their attributes. Empty fields match everything;
+// all non-empty fields must match.
+type Selector struct {
+	Label   string `json:"label,omitempty"` // "key" to require a label, "key=value" to match its value
+	Image   string `json:"image,omitempty"` // Image name, glob patterns allowed
+	Name    string `json:"name,omitempty"`  // Container name, glob patterns allowed
+	State   string `json:"state,omitempty"`
+	Project string `json:"project,omitempty"` // Docker Compose project
+}
+
+// Empty reports whether the selector has no conditions
+func (sel Selector) Empty() bool {
+	return sel == Selector{}
+}
+
+// Match reports whether a container satisfies every condition of the selector
+func (sel Selector) Match(c store.ContainerData) bool {
+	if sel.State != "" && c.State != sel.State {
+		return false
+	}
+	if sel.Project != "" && (c.Compose == nil || c.Compose.Project != sel.Project) {
+		return false
+	}
+	if sel.Image != "" && !globMatch(sel.Image, c.Image) {
+		return false
+	}
+	if sel.Name != "" && !matchAnyName(sel.Name, c.Names) {
+		return false
+	}
+	if sel.Label != "" {
+		key, value, hasValue := strings.Cut(sel.Label, "=")
+		actual, exists := c.Labels[key]
+		if !exists || (hasValue && actual != value) {
+			return false
+		}
+	}
+	return true
+}
+
+// containerName returns the primary name of a container without Docker's leading slash
+func containerName(c store.ContainerData) string {
+	if len(c.Names) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(c.Names[0], "/")
+}
+
+func matchAnyName(pattern string, names []string) bool {
+	for _, name := range names {
+		if globMatch(pattern, strings.TrimPrefix(name, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// globMatch matches value against a shell pattern, treating malformed patterns as literals
+func globMatch(pattern, value string) bool {
+	matched, err := path.Match(pattern, value)
+	if err != nil {
+		return pattern == value
+	}
+	return matched
+}
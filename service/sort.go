@@ -0,0 +1,208 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yarlson/duh/store"
+)
+
+// Sort fields accepted by ParseSort
+const (
+	SortState         = "state" // unhealthy, running, error, stopping, starting, exited, others
+	SortCPU           = "cpu"
+	SortMemory        = "memory"
+	SortMemoryPercent = "memory_percent" // Memory usage as a share of the limit
+	SortName          = "name"
+	SortImage         = "image"
+	SortCreated       = "created"
+	SortUptime        = "uptime"
+	SortNetwork       = "network" // Combined receive and transmit throughput
+)
+
+// ErrInvalidSort is returned by ParseSort for unknown sort fields
+var ErrInvalidSort = errors.New("invalid sort")
+
+// SortKey orders containers by one field
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// DefaultSort lists running containers first, then the largest memory users and
+// the most recently created containers
+var DefaultSort = []SortKey{
+	{Field: SortState},
+	{Field: SortMemory, Desc: true},
+	{Field: SortCreated, Desc: true},
+}
+
+// ParseSort parses a comma separated list of sort fields. A field prefixed with -
+// sorts descending, otherwise ascending. An empty string yields DefaultSort.
+func ParseSort(value string) ([]SortKey, error) {
+	var keys []SortKey
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key := SortKey{Field: strings.TrimPrefix(part, "+")}
+		if strings.HasPrefix(part, "-") {
+			key = SortKey{Field: part[1:], Desc: true}
+		}
+		if _, ok := sortFields[key.Field]; !ok {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidSort, key.Field)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return DefaultSort, nil
+	}
+	return keys, nil
+}
+
+// SortContainers sorts containers by each key in turn, breaking remaining ties by
+// ID. Containers without stats sort after those with stats for the stats fields,
+// whichever the direction.
+func SortContainers(containers []store.ContainerData, keys ...SortKey) {
+	if len(keys) == 0 {
+		keys = DefaultSort
+	}
+	sort.SliceStable(containers, func(i, j int) bool {
+		a, b := containers[i], containers[j]
+		for _, key := range keys {
+			c := sortFields[key.Field](a, b)
+			if c == 0 {
+				continue
+			}
+			if key.Desc && c != missingFirst && c != missingLast {
+				c = -c
+			}
+			return c < 0
+		}
+		return a.ID < b.ID
+	})
+}
+
+// Results of comparing a container with stats against one without. They keep their
+// sign when a key is reversed, so containers without stats always sort last.
+const (
+	missingLast  = -2
+	missingFirst = 2
+)
+
+// sortFields compare two containers by one field, returning a negative number when
+// a sorts before b in ascending order
+var sortFields = map[string]func(a, b store.ContainerData) int{
+	SortState: func(a, b store.ContainerData) int {
+		return compareInt(int64(getStatusPriority(a)), int64(getStatusPriority(b)))
+	},
+	SortCPU: statsField(func(s *store.Stats) float64 { return s.CPU.Usage }),
+	SortMemory: statsField(func(s *store.Stats) float64 {
+		return float64(s.Memory.Usage)
+	}),
+	SortMemoryPercent: statsField(func(s *store.Stats) float64 {
+		if s.Memory.Limit == 0 {
+			return 0
+		}
+		return float64(s.Memory.Usage) / float64(s.Memory.Limit)
+	}),
+	SortNetwork: statsField(func(s *store.Stats) float64 {
+		return s.Network.RxRate + s.Network.TxRate
+	}),
+	SortName: func(a, b store.ContainerData) int {
+		return strings.Compare(strings.ToLower(containerName(a)), strings.ToLower(containerName(b)))
+	},
+	SortImage: func(a, b store.ContainerData) int {
+		return strings.Compare(a.Image, b.Image)
+	},
+	SortCreated: func(a, b store.ContainerData) int {
+		return compareInt(a.Created, b.Created)
+	},
+	SortUptime: func(a, b store.ContainerData) int {
+		return compareInt(int64(parseUptime(a.Status)), int64(parseUptime(b.Status)))
+	},
+}
+
+func statsField(value func(*store.Stats) float64) func(a, b store.ContainerData) int {
+	return func(a, b store.ContainerData) int {
+		switch {
+		case a.Stats == nil && b.Stats == nil:
+			return 0
+		case a.Stats == nil:
+			return missingFirst
+		case b.Stats == nil:
+			return missingLast
+		}
+		va, vb := value(a.Stats), value(b.Stats)
+		switch {
+		case va < vb:
+			return -1
+		case va > vb:
+			return 1
+		}
+		return 0
+	}
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// parseUptime reads how long a container has been up from a Docker status such as
+// "Up 2 hours (healthy)". It returns 0 for containers that are not up.
+func parseUptime(status string) time.Duration {
+	rest, ok := strings.CutPrefix(status, "Up ")
+	if !ok {
+		return 0
+	}
+	if i := strings.IndexByte(rest, '('); i >= 0 {
+		rest = rest[:i]
+	}
+	rest = strings.TrimSpace(rest)
+
+	switch strings.ToLower(rest) {
+	case "less than a second":
+		return time.Second / 2
+	case "about a minute":
+		return time.Minute
+	case "about an hour":
+		return time.Hour
+	}
+
+	count, unit, ok := strings.Cut(rest, " ")
+	if !ok {
+		return 0
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		return 0
+	}
+	switch strings.TrimSuffix(unit, "s") {
+	case "second":
+		return time.Duration(n) * time.Second
+	case "minute":
+		return time.Duration(n) * time.Minute
+	case "hour":
+		return time.Duration(n) * time.Hour
+	case "day":
+		return time.Duration(n) * 24 * time.Hour
+	case "week":
+		return time.Duration(n) * 7 * 24 * time.Hour
+	case "month":
+		return time.Duration(n) * 30 * 24 * time.Hour
+	case "year":
+		return time.Duration(n) * 365 * 24 * time.Hour
+	}
+	return 0
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yarlson/duh/docker"
+	"github.com/yarlson/duh/store"
+)
+
+func statsWith(cpu float64, memUsage, memLimit uint64, netRate float64) *store.Stats {
+	stats := &store.Stats{}
+	stats.CPU.Usage = cpu
+	stats.Memory.Usage = memUsage
+	stats.Memory.Limit = memLimit
+	stats.Network.RxRate = netRate
+	return stats
+}
+
+func TestSortContainersByKeys(t *testing.T) {
+	containers := []store.ContainerData{
+		{ID: "a", Names: []string{"/web"}, Image: "nginx", State: "running", Status: "Up 2 hours", Created: 3,
+			Stats: statsWith(10, 200, 1000, 50)},
+		{ID: "b", Names: []string{"/Api"}, Image: "node", State: "running", Status: "Up 3 days", Created: 1,
+			Stats: statsWith(10, 100, 200, 500)},
+		{ID: "c", Names: []string{"/db"}, Image: "postgres", State: "exited", Status: "Exited (0) 1 hour ago", Created: 2},
+		{ID: "d", Names: []string{"/cache"}, Image: "redis", State: "running", Status: "Up About a minute", Created: 4,
+			Stats: statsWith(80, 50, 0, 0)},
+	}
+
+	testCases := []struct {
+		sort string
+		want string
+	}{
+		{"", "a,b,d,c"},
+		{"-cpu", "d,a,b,c"},
+		{"cpu,-memory", "a,b,d,c"},
+		{"memory", "d,b,a,c"},
+		{"-memory_percent", "b,a,d,c"},
+		{"name", "b,d,c,a"},
+		{"-image", "d,c,b,a"},
+		{"created", "b,c,a,d"},
+		{"-uptime", "b,a,d,c"},
+		{"-network", "b,a,d,c"},
+	}
+
+	for _, tc := range testCases {
+		keys, err := ParseSort(tc.sort)
+		if err != nil {
+			t.Fatalf("ParseSort(%q) failed: %v", tc.sort, err)
+		}
+		sorted := append([]store.ContainerData(nil), containers...)
+		SortContainers(sorted, keys...)
+
+		var ids []string
+		for _, c := range sorted {
+			ids = append(ids, c.ID)
+		}
+		if got := strings.Join(ids, ","); got != tc.want {
+			t.Errorf("Sort %q: got %s, want %s", tc.sort, got, tc.want)
+		}
+	}
+
+	if _, err := ParseSort("cpu,colour"); !errors.Is(err, ErrInvalidSort) {
+		t.Errorf("Expected ErrInvalidSort, got %v", err)
+	}
+}
+
+func TestParseUptime(t *testing.T) {
+	testCases := map[string]time.Duration{
+		"Up 5 seconds":             5 * time.Second,
+		"Up About a minute":        time.Minute,
+		"Up 2 hours (healthy)":     2 * time.Hour,
+		"Up 3 days":                72 * time.Hour,
+		"Up Less than a second":    time.Second / 2,
+		"Up 1 week (Paused)":       7 * 24 * time.Hour,
+		"Exited (0) 2 minutes ago": 0,
+		"Created":                  0,
+	}
+	for status, want := range testCases {
+		if got := parseUptime(status); got != want {
+			t.Errorf("parseUptime(%q) = %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestServiceNetworkThroughput(t *testing.T) {
+	sampled := time.Now()
+	var rx uint64
+	mockDocker := &DockerClientMock{
+		GetContainerStatsFunc: func(ctx context.Context, id string) (*docker.ContainerStats, error) {
+			stats := &docker.ContainerStats{
+				Read: sampled,
+				Networks: map[string]docker.NetworkStats{
+					"eth0": {RxBytes: rx, TxBytes: rx / 2},
+					"eth1": {RxBytes: rx},
+				},
+			}
+			return stats, nil
+		},
+	}
+
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(store.ContainerData{ID: "web", State: "running"})
+	service := New(mockDocker, memoryStore)
+	containers := []docker.Container{{ID: "web", State: "running"}}
+
+	rx = 1000
+	service.SyncStats(context.Background(), containers)
+	sampled = sampled.Add(2 * time.Second)
+	rx = 3000
+	service.SyncStats(context.Background(), containers)
+
+	container, _ := service.Get("web")
+	network := container.Stats.Network
+	if network.RxBytes != 6000 || network.TxBytes != 1500 {
+		t.Errorf("Expected totals 6000/1500, got %d/%d", network.RxBytes, network.TxBytes)
+	}
+	if network.RxRate != 2000 || network.TxRate != 500 {
+		t.Errorf("Expected rates 2000/500, got %v/%v", network.RxRate, network.TxRate)
+	}
+}
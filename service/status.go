@@ -0,0 +1,145 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yarlson/duh/store"
+)
+
+// CriticalLabel marks a container as critical for Status when set to "true"
+const CriticalLabel = "duh.critical"
+
+// Overall statuses reported by Status
+const (
+	StatusOK       = "ok"       // Every critical container is working
+	StatusDegraded = "degraded" // Some critical containers are failing
+	StatusDown     = "down"     // All critical containers are failing, or Docker is unreachable
+)
+
+// Status is the overall health of the critical containers
+type Status struct {
+	Status   string             `json:"status"`
+	Critical int                `json:"critical"` // Number of critical containers
+	Failing  []FailingContainer `json:"failing"`
+	Error    string             `json:"error,omitempty"` // Set when Docker could not be reached
+}
+
+// FailingContainer is a critical container that is not working
+type FailingContainer struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	State  string `json:"state"`
+	Reason string `json:"reason"`
+}
+
+// WithCritical marks the containers matching the filter as critical, in addition to
+// those labelled duh.critical=true
+func WithCritical(filter Filter) Option {
+	return func(s *ContainerService) {
+		s.critical = filter
+	}
+}
+
+// unmatchedCritical names the failing entry reported when the critical filter set
+// with WithCritical matches no container at all
+const unmatchedCritical = "critical selector"
+
+// Status reports whether the critical containers are running, healthy and passing
+// their probes. Critical containers removed from Docker and not replaced by one of the
+// same name fail as removed, and a critical filter matching no container fails too.
+// Without critical containers the status is ok.
+func (s *ContainerService) Status() Status {
+	status := Status{Status: StatusOK, Failing: []FailingContainer{}}
+	if err := s.SyncMetrics().LastError; err != "" {
+		status.Status = StatusDown
+		status.Error = err
+		return status
+	}
+
+	containers := s.store.List()
+	live := make(map[string]bool, len(containers))
+	for _, c := range containers {
+		live[containerName(c)] = true
+	}
+	// Removed containers still count unless they were recreated under the same name,
+	// as docker compose up does
+	for _, tombstone := range s.store.Tombstones() {
+		if !live[containerName(tombstone)] {
+			live[containerName(tombstone)] = true
+			containers = append(containers, tombstone)
+		}
+	}
+
+	matched := false
+	for _, c := range containers {
+		if s.critical != nil && s.critical(c) {
+			matched = true
+		}
+		if !s.isCritical(c) {
+			continue
+		}
+		status.Critical++
+		if reason := failingReason(c); reason != "" {
+			status.Failing = append(status.Failing, FailingContainer{
+				ID:     c.ID,
+				Name:   containerName(c),
+				State:  c.State,
+				Reason: reason,
+			})
+		}
+	}
+	if s.critical != nil && !matched {
+		status.Critical++
+		status.Failing = append(status.Failing, FailingContainer{
+			Name:   unmatchedCritical,
+			Reason: "no container matches",
+		})
+	}
+	sort.Slice(status.Failing, func(i, j int) bool {
+		return status.Failing[i].Name < status.Failing[j].Name
+	})
+
+	switch {
+	case len(status.Failing) == 0:
+	case len(status.Failing) == status.Critical:
+		status.Status = StatusDown
+	default:
+		status.Status = StatusDegraded
+	}
+	return status
+}
+
+func (s *ContainerService) isCritical(c store.ContainerData) bool {
+	return labelBool(c.Labels, CriticalLabel) || (s.critical != nil && s.critical(c))
+}
+
+// failingReason explains why a container is not working, or returns an empty string
+func failingReason(c store.ContainerData) string {
+	if c.Removed {
+		return "removed"
+	}
+	if c.State != "running" {
+		if c.ExitCode != nil {
+			return fmt.Sprintf("%s with code %d", c.State, *c.ExitCode)
+		}
+		if c.Error != "" {
+			return fmt.Sprintf("%s: %s", c.State, c.Error)
+		}
+		return c.State
+	}
+	if c.Health != nil && c.Health.Status == store.HealthUnhealthy {
+		return "unhealthy"
+	}
+	var down []string
+	for _, probe := range c.Probes {
+		if probe.Status == store.ProbeDown {
+			down = append(down, probe.Type+" probe of "+probe.Target)
+		}
+	}
+	if len(down) > 0 {
+		return strings.Join(down, ", ") + " failing"
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yarlson/duh/docker"
+	"github.com/yarlson/duh/store"
+)
+
+func TestServiceStatus(t *testing.T) {
+	critical := map[string]string{CriticalLabel: "true"}
+	exitCode := 1
+
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(store.ContainerData{ID: "1", Names: []string{"/web"}, State: "running", Labels: critical,
+		Health: &store.Health{Status: store.HealthHealthy}})
+	memoryStore.Update(store.ContainerData{ID: "2", Names: []string{"/batch"}, State: "exited", ExitCode: &exitCode})
+	filter, err := ParseQuery("project:shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := New(&DockerClientMock{}, memoryStore, WithCritical(filter))
+
+	// The critical selector matches nothing yet
+	status := service.Status()
+	if status.Status != StatusDegraded || status.Critical != 2 || len(status.Failing) != 1 ||
+		status.Failing[0].Name != unmatchedCritical {
+		t.Fatalf("Expected degraded for the unmatched selector, got %+v", status)
+	}
+
+	memoryStore.Update(store.ContainerData{ID: "3", Names: []string{"/db"}, State: "exited", ExitCode: &exitCode,
+		Compose: &store.ComposeInfo{Project: "shop"}})
+	memoryStore.Update(store.ContainerData{ID: "4", Names: []string{"/api"}, State: "running", Labels: critical})
+	memoryStore.SetProbes("4", []store.Probe{{Type: store.ProbeHTTP, Target: "http://127.0.0.1:8080/healthz", Status: store.ProbeDown}})
+
+	status = service.Status()
+	if status.Status != StatusDegraded || status.Critical != 3 || len(status.Failing) != 2 {
+		t.Fatalf("Expected degraded with two failing containers, got %+v", status)
+	}
+	if status.Failing[0].Name != "api" || status.Failing[0].Reason != "http probe of http://127.0.0.1:8080/healthz failing" {
+		t.Errorf("Unexpected failing container %+v", status.Failing[0])
+	}
+	if status.Failing[1].Name != "db" || status.Failing[1].Reason != "exited with code 1" {
+		t.Errorf("Unexpected failing container %+v", status.Failing[1])
+	}
+
+	memoryStore.Update(store.ContainerData{ID: "1", Names: []string{"/web"}, State: "running", Labels: critical,
+		Status: "Up 1 hour (unhealthy)", Health: &store.Health{Status: store.HealthUnhealthy}})
+	if status = service.Status(); status.Status != StatusDown || len(status.Failing) != 3 {
+		t.Errorf("Expected down with every critical container failing, got %+v", status)
+	}
+}
+
+func TestServiceStatusRemovedCritical(t *testing.T) {
+	critical := map[string]string{CriticalLabel: "true"}
+	memoryStore := store.NewStore(time.Minute)
+	memoryStore.Update(store.ContainerData{ID: "1", Names: []string{"/web"}, State: "running", Labels: critical})
+	memoryStore.Update(store.ContainerData{ID: "2", Names: []string{"/api"}, State: "running", Labels: critical})
+	service := New(&DockerClientMock{}, memoryStore)
+
+	memoryStore.Remove("1")
+	status := service.Status()
+	if status.Status != StatusDegraded || status.Critical != 2 || len(status.Failing) != 1 {
+		t.Fatalf("Expected degraded with the removed container failing, got %+v", status)
+	}
+	if failing := status.Failing[0]; failing.ID != "1" || failing.Reason != "removed" {
+		t.Errorf("Unexpected failing container %+v", failing)
+	}
+
+	// Recreated under the same name, e.g. by docker compose up
+	memoryStore.Update(store.ContainerData{ID: "3", Names: []string{"/web"}, State: "running", Labels: critical})
+	if status = service.Status(); status.Status != StatusOK || status.Critical != 2 {
+		t.Errorf("Expected ok once the container is replaced, got %+v", status)
+	}
+}
+
+func TestServiceStatusDockerUnreachable(t *testing.T) {
+	mockDocker := &DockerClientMock{
+		ListContainersFunc: func(ctx context.Context, all bool) ([]docker.Container, error) {
+			return nil, errors.New("connection refused")
+		},
+	}
+	service := New(mockDocker, store.NewStore(time.Minute))
+
+	if err := service.Sync(context.Background()); err == nil {
+		t.Fatal("Expected the sync to fail")
+	}
+	if status := service.Status(); status.Status != StatusDown || status.Error != "connection refused" {
+		t.Errorf("Expected down with the Docker error, got %+v", status)
+	}
+}
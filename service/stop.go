@@ -0,0 +1,58 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yarlson/duh/docker"
+)
+
+// Labels that set per-container stop defaults. The timeout is either a number of
+// seconds or a Go duration such as 90s or 2m.
+const (
+	StopTimeoutLabel = "duh.stop-timeout"
+	StopSignalLabel  = "duh.stop-signal"
+)
+
+// stopOptions fills in the timeout and signal left unset by the caller from the
+// container's labels. Invalid label values are ignored.
+func stopOptions(labels map[string]string, opts docker.StopOptions) docker.StopOptions {
+	if opts.Timeout == nil {
+		if timeout, ok := parseStopTimeout(labels[StopTimeoutLabel]); ok {
+			opts.Timeout = &timeout
+		}
+	}
+	if opts.Signal == "" {
+		opts.Signal = strings.TrimSpace(labels[StopSignalLabel])
+	}
+	return opts
+}
+
+// parseStopTimeout parses a stop timeout label into whole seconds
+func parseStopTimeout(value string) (int, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return seconds, true
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, false
+	}
+	return int((d + time.Second - 1) / time.Second), true
+}
+
+// stopWindow is how long a stop may take: Docker waits up to the stop timeout
+// before killing the container, so the transition timeout starts after it
+func (s *ContainerService) stopWindow(opts docker.StopOptions) time.Duration {
+	if opts.Timeout == nil || *opts.Timeout <= 0 {
+		return s.transitionTimeout
+	}
+	return s.transitionTimeout + time.Duration(*opts.Timeout)*time.Second
+}
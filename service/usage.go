@@ -0,0 +1,196 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yarlson/duh/store"
+)
+
+// Usage groupings accepted by UsageRequest.By. Labels are grouped by value with
+// "label:<key>".
+const (
+	UsageByImage   = "image"
+	UsageByProject = "project"
+	UsageByHost    = "host" // A single group with every container
+	usageByLabel   = "label:"
+)
+
+// Usage metrics that order groups and top contributors
+const (
+	UsageCPU     = "cpu"
+	UsageMemory  = "memory"
+	UsageNetwork = "network" // Combined receive and transmit rate
+	UsageBlockIO = "blkio"   // Bytes read and written
+)
+
+const (
+	DefaultUsageTop = 5
+	MaxUsageTop     = 100
+)
+
+// ErrInvalidUsage is returned for unknown groupings and metrics
+var ErrInvalidUsage = errors.New("invalid usage request")
+
+// UsageRequest selects how Usage groups and orders containers
+type UsageRequest struct {
+	By      string   // UsageByImage, UsageByProject, UsageByHost or "label:<key>"
+	Metric  string   // Metric to order by, UsageMemory when empty
+	Top     int      // Contributors listed per group, DefaultUsageTop when zero
+	Filters []Filter // Containers to include, all when empty
+}
+
+// UsageGroup sums the resource usage of the containers sharing a key. Containers
+// without a project or the grouping label have an empty key.
+type UsageGroup struct {
+	Key         string             `json:"key"`
+	Containers  int                `json:"containers"`
+	States      map[string]int     `json:"states"`
+	CPU         float64            `json:"cpu"` // Sum of container CPU percentages
+	MemoryUsage uint64             `json:"memory_usage"`
+	MemoryLimit uint64             `json:"memory_limit"`
+	RxBytes     uint64             `json:"rx_bytes"`
+	TxBytes     uint64             `json:"tx_bytes"`
+	RxRate      float64            `json:"rx_rate"`
+	TxRate      float64            `json:"tx_rate"`
+	ReadBytes   uint64             `json:"read_bytes"`
+	WriteBytes  uint64             `json:"write_bytes"`
+	Top         []UsageContributor `json:"top"` // Largest contributors by the request metric
+}
+
+// UsageContributor is one container's share of a group
+type UsageContributor struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	CPU         float64 `json:"cpu"`
+	MemoryUsage uint64  `json:"memory_usage"`
+	NetworkRate float64 `json:"network_rate"`
+	BlockIO     uint64  `json:"blkio"`
+}
+
+// Usage aggregates the usage of the stored containers into groups, largest first by
+// the request metric
+func (s *ContainerService) Usage(req UsageRequest) ([]UsageGroup, error) {
+	groupKey, err := usageGrouping(req.By)
+	if err != nil {
+		return nil, err
+	}
+	if req.Metric == "" {
+		req.Metric = UsageMemory
+	}
+	metric, ok := usageMetrics[req.Metric]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown metric %q", ErrInvalidUsage, req.Metric)
+	}
+	top := req.Top
+	if top <= 0 {
+		top = DefaultUsageTop
+	}
+	if top > MaxUsageTop {
+		top = MaxUsageTop
+	}
+
+	groups := make(map[string]*UsageGroup)
+	members := make(map[string][]UsageContributor)
+	for _, c := range FilterContainers(s.store.List(), req.Filters...) {
+		key := groupKey(c)
+		group := groups[key]
+		if group == nil {
+			group = &UsageGroup{Key: key, States: make(map[string]int)}
+			groups[key] = group
+		}
+		group.Containers++
+		group.States[c.State]++
+
+		contributor := UsageContributor{ID: c.ID, Name: containerName(c)}
+		if stats := c.Stats; stats != nil {
+			group.CPU += stats.CPU.Usage
+			group.MemoryUsage += stats.Memory.Usage
+			group.MemoryLimit += stats.Memory.Limit
+			group.RxBytes += stats.Network.RxBytes
+			group.TxBytes += stats.Network.TxBytes
+			group.RxRate += stats.Network.RxRate
+			group.TxRate += stats.Network.TxRate
+			group.ReadBytes += stats.BlockIO.ReadBytes
+			group.WriteBytes += stats.BlockIO.WriteBytes
+
+			contributor.CPU = stats.CPU.Usage
+			contributor.MemoryUsage = stats.Memory.Usage
+			contributor.NetworkRate = stats.Network.RxRate + stats.Network.TxRate
+			contributor.BlockIO = stats.BlockIO.ReadBytes + stats.BlockIO.WriteBytes
+		}
+		members[key] = append(members[key], contributor)
+	}
+
+	result := make([]UsageGroup, 0, len(groups))
+	for key, group := range groups {
+		contributors := members[key]
+		sort.SliceStable(contributors, func(i, j int) bool {
+			a, b := metric.contributor(contributors[i]), metric.contributor(contributors[j])
+			if a != b {
+				return a > b
+			}
+			return contributors[i].ID < contributors[j].ID
+		})
+		if len(contributors) > top {
+			contributors = contributors[:top]
+		}
+		group.Top = contributors
+		result = append(result, *group)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := metric.group(result[i]), metric.group(result[j])
+		if a != b {
+			return a > b
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result, nil
+}
+
+// usageGrouping returns the function that keys a container for a grouping
+func usageGrouping(by string) (func(store.ContainerData) string, error) {
+	switch by {
+	case UsageByImage:
+		return func(c store.ContainerData) string { return c.Image }, nil
+	case UsageByProject:
+		return func(c store.ContainerData) string {
+			if c.Compose == nil {
+				return ""
+			}
+			return c.Compose.Project
+		}, nil
+	case UsageByHost, "":
+		return func(store.ContainerData) string { return UsageByHost }, nil
+	}
+	if key, ok := strings.CutPrefix(by, usageByLabel); ok && key != "" {
+		return func(c store.ContainerData) string { return c.Labels[key] }, nil
+	}
+	return nil, fmt.Errorf("%w: unknown grouping %q", ErrInvalidUsage, by)
+}
+
+type usageMetric struct {
+	group       func(UsageGroup) float64
+	contributor func(UsageContributor) float64
+}
+
+var usageMetrics = map[string]usageMetric{
+	UsageCPU: {
+		group:       func(g UsageGroup) float64 { return g.CPU },
+		contributor: func(c UsageContributor) float64 { return c.CPU },
+	},
+	UsageMemory: {
+		group:       func(g UsageGroup) float64 { return float64(g.MemoryUsage) },
+		contributor: func(c UsageContributor) float64 { return float64(c.MemoryUsage) },
+	},
+	UsageNetwork: {
+		group:       func(g UsageGroup) float64 { return g.RxRate + g.TxRate },
+		contributor: func(c UsageContributor) float64 { return c.NetworkRate },
+	},
+	UsageBlockIO: {
+		group:       func(g UsageGroup) float64 { return float64(g.ReadBytes + g.WriteBytes) },
+		contributor: func(c UsageContributor) float64 { return float64(c.BlockIO) },
+	},
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yarlson/duh/store"
+)
+
+func usageStats(cpu float64, memory uint64, rxRate float64, read uint64) *store.Stats {
+	stats := &store.Stats{}
+	stats.CPU.Usage = cpu
+	stats.Memory.Usage = memory
+	stats.Memory.Limit = 4 << 30
+	stats.Network.RxRate = rxRate
+	stats.BlockIO.ReadBytes = read
+	return stats
+}
+
+func TestServiceUsage(t *testing.T) {
+	memoryStore := store.NewStore(time.Minute)
+	for _, c := range []store.ContainerData{
+		{ID: "1", Names: []string{"/shop-web-1"}, Image: "nginx", State: "running",
+			Labels: map[string]string{"team": "web"}, Compose: &store.ComposeInfo{Project: "shop"}},
+		{ID: "2", Names: []string{"/shop-db-1"}, Image: "postgres", State: "running",
+			Labels: map[string]string{"team": "data"}, Compose: &store.ComposeInfo{Project: "shop"}},
+		{ID: "3", Names: []string{"/blog"}, Image: "nginx", State: "running", Labels: map[string]string{"team": "web"}},
+		{ID: "4", Names: []string{"/old"}, Image: "nginx", State: "exited"},
+	} {
+		memoryStore.Update(c)
+	}
+	memoryStore.UpdateStats("1", usageStats(5, 1<<30, 100, 0))
+	memoryStore.UpdateStats("2", usageStats(20, 8<<30, 10, 1000))
+	memoryStore.UpdateStats("3", usageStats(50, 2<<30, 500, 0))
+	service := New(&DockerClientMock{}, memoryStore)
+
+	groups, err := service.Usage(UsageRequest{By: UsageByProject, Top: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 || groups[0].Key != "shop" || groups[1].Key != "" {
+		t.Fatalf("Expected shop before ungrouped containers, got %+v", groups)
+	}
+	shop := groups[0]
+	if shop.Containers != 2 || shop.MemoryUsage != 9<<30 || shop.CPU != 25 || shop.RxRate != 110 || shop.ReadBytes != 1000 {
+		t.Errorf("Unexpected shop totals %+v", shop)
+	}
+	if len(shop.Top) != 1 || shop.Top[0].Name != "shop-db-1" {
+		t.Errorf("Expected the database as top contributor, got %+v", shop.Top)
+	}
+	if groups[1].States["running"] != 1 || groups[1].States["exited"] != 1 {
+		t.Errorf("Unexpected state counts %+v", groups[1].States)
+	}
+
+	groups, err = service.Usage(UsageRequest{By: "label:team", Metric: UsageCPU})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 3 || groups[0].Key != "web" || groups[0].CPU != 55 || groups[0].Top[0].ID != "3" {
+		t.Errorf("Unexpected label groups %+v", groups)
+	}
+
+	groups, err = service.Usage(UsageRequest{By: UsageByImage, Metric: UsageNetwork, Filters: []Filter{MatchState("running")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 || groups[0].Key != "nginx" || groups[0].Containers != 2 {
+		t.Errorf("Unexpected image groups %+v", groups)
+	}
+
+	groups, err = service.Usage(UsageRequest{By: UsageByHost})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 || groups[0].Containers != 4 || groups[0].MemoryUsage != 11<<30 {
+		t.Errorf("Unexpected host totals %+v", groups)
+	}
+
+	for _, req := range []UsageRequest{{By: "team"}, {By: "label:"}, {By: UsageByHost, Metric: "disk"}} {
+		if _, err := service.Usage(req); !errors.Is(err, ErrInvalidUsage) {
+			t.Errorf("Usage(%+v) error = %v, want ErrInvalidUsage", req, err)
+		}
+	}
+}
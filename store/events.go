@@ -0,0 +1,174 @@
+package store
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+const (
+	// DefaultSubscriberBuffer is the channel buffer used when Subscribe is called with a non-positive size
+	DefaultSubscriberBuffer = 256
+
+	// historySize is the number of recent events kept for EventsSince
+	historySize = 1024
+)
+
+// EventType identifies the kind of change described by an Event
+type EventType string
+
+const (
+	EventAdded        EventType = "added"
+	EventUpdated      EventType = "updated"
+	EventStatsUpdated EventType = "stats-updated"
+	EventRemoved      EventType = "removed"
+)
+
+// Event describes a single change to the container data held by the store
+type Event struct {
+	Seq       uint64        `json:"seq"`
+	Type      EventType     `json:"type"`
+	Container ContainerData `json:"container"`
+	Time      time.Time     `json:"time"`
+}
+
+type subscriber struct {
+	ch   chan Event
+	done chan struct{}
+}
+
+// Subscribe returns a channel that receives every change made to the store from now on.
+// The channel is closed when ctx is cancelled, when the store is closed, or when the
+// subscriber falls behind and its buffer fills up. A subscriber whose channel closes
+// while ctx is still active has been dropped as a slow consumer and should resync
+// from List before subscribing again.
+func (s *Store) Subscribe(ctx context.Context, buffer int) <-chan Event {
+	if buffer <= 0 {
+		buffer = DefaultSubscriberBuffer
+	}
+
+	sub := &subscriber{
+		ch:   make(chan Event, buffer),
+		done: make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	select {
+	case <-s.done:
+		s.mu.Unlock()
+		close(sub.ch)
+		return sub.ch
+	default:
+	}
+	s.subscribers[sub] = struct{}{}
+	s.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-s.done:
+		case <-sub.done:
+			return
+		}
+		s.mu.Lock()
+		s.unsubscribe(sub)
+		s.mu.Unlock()
+	}()
+
+	return sub.ch
+}
+
+// unsubscribe removes a subscriber and closes its channel. Callers must hold s.mu.
+func (s *Store) unsubscribe(sub *subscriber) {
+	if _, ok := s.subscribers[sub]; !ok {
+		return
+	}
+	delete(s.subscribers, sub)
+	close(sub.ch)
+	close(sub.done)
+}
+
+// commit applies a change under a new store version and publishes it. Callers must hold s.mu.
+func (s *Store) commit(typ EventType, container ContainerData) {
+	s.seq++
+	container.Version = s.seq
+
+	if typ == EventRemoved {
+		now := time.Now()
+		container.Removed = true
+		container.RemovedAt = &now
+		delete(s.containers, container.ID)
+		s.tombstones[container.ID] = container
+	} else {
+		delete(s.tombstones, container.ID)
+		s.containers[container.ID] = container
+	}
+
+	s.publish(typ, container)
+}
+
+// publish delivers an event for the current version to all subscribers without blocking.
+// Subscribers whose buffer is full are dropped. Callers must hold s.mu so that events
+// are delivered in the order the changes were applied.
+func (s *Store) publish(typ EventType, container ContainerData) {
+	event := Event{
+		Seq:       s.seq,
+		Type:      typ,
+		Container: container,
+		Time:      time.Now(),
+	}
+
+	if len(s.history) == historySize {
+		copy(s.history, s.history[1:])
+		s.history = s.history[:historySize-1]
+	}
+	s.history = append(s.history, event)
+
+	for sub := range s.subscribers {
+		select {
+		case sub.ch <- event:
+		default:
+			s.unsubscribe(sub)
+		}
+	}
+}
+
+// EventsSince returns the retained events with a sequence number greater than seq.
+// The boolean is false when events after seq have already been discarded, in which
+// case the caller must fall back to a full snapshot.
+func (s *Store) EventsSince(seq uint64) ([]Event, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if seq > s.seq {
+		return nil, false
+	}
+	if seq == s.seq {
+		return nil, true
+	}
+	if len(s.history) == 0 || s.history[0].Seq > seq+1 {
+		return nil, false
+	}
+
+	start := len(s.history) - int(s.seq-seq)
+	result := make([]Event, len(s.history)-start)
+	copy(result, s.history[start:])
+	return result, true
+}
+
+// Snapshot returns all non-stale container data together with the sequence number
+// of the last event applied to it, so that subscribers can skip events already
+// reflected in the snapshot
+func (s *Store) Snapshot() ([]ContainerData, uint64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.list(), s.seq
+}
+
+// sameContainer reports whether two records differ only in their internal TTL timestamp
+func sameContainer(a, b ContainerData) bool {
+	a.Updated = time.Time{}
+	b.Updated = time.Time{}
+	return reflect.DeepEqual(a, b)
+}
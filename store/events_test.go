@@ -0,0 +1,142 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func receiveEvent(t *testing.T, ch <-chan Event) Event {
+	t.Helper()
+	select {
+	case ev, ok := <-ch:
+		if !ok {
+			t.Fatal("Event channel closed unexpectedly")
+		}
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for event")
+	}
+	return Event{}
+}
+
+func TestStoreSubscribe(t *testing.T) {
+	store := NewStore(time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := store.Subscribe(ctx, 0)
+
+	store.Update(ContainerData{ID: "123", State: "running"})
+	if ev := receiveEvent(t, events); ev.Type != EventAdded || ev.Container.ID != "123" {
+		t.Errorf("Got %s event for %q, want %s for 123", ev.Type, ev.Container.ID, EventAdded)
+	}
+
+	// An update that changes nothing visible must not produce an event
+	store.Update(ContainerData{ID: "123", State: "running"})
+	store.Update(ContainerData{ID: "123", State: "exited"})
+	ev := receiveEvent(t, events)
+	if ev.Type != EventUpdated || ev.Container.State != "exited" {
+		t.Errorf("Got %s event with state %q, want %s with exited", ev.Type, ev.Container.State, EventUpdated)
+	}
+
+	store.UpdateStats("123", &Stats{})
+	if ev := receiveEvent(t, events); ev.Type != EventStatsUpdated {
+		t.Errorf("Got %s event, want %s", ev.Type, EventStatsUpdated)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	store.RemoveStaleData()
+	last := receiveEvent(t, events)
+	if last.Type != EventRemoved || last.Container.ID != "123" {
+		t.Errorf("Got %s event for %q, want %s for 123", last.Type, last.Container.ID, EventRemoved)
+	}
+
+	if last.Seq <= ev.Seq {
+		t.Errorf("Event sequence not increasing: %d after %d", last.Seq, ev.Seq)
+	}
+}
+
+func TestStoreUnsubscribeOnCancel(t *testing.T) {
+	store := NewStore(time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	events := store.Subscribe(ctx, 1)
+	cancel()
+
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Fatal("Expected channel to be closed after cancel")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Channel not closed after cancel")
+	}
+
+	// Publishing after unsubscribe must not panic
+	store.Update(ContainerData{ID: "123"})
+}
+
+func TestStoreSlowSubscriberDropped(t *testing.T) {
+	store := NewStore(time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	slow := store.Subscribe(ctx, 1)
+	fast := store.Subscribe(ctx, 10)
+
+	store.Update(ContainerData{ID: "1"})
+	store.Update(ContainerData{ID: "2"})
+
+	// The slow subscriber keeps the event it had room for, then sees the channel closed
+	receiveEvent(t, slow)
+	if _, ok := <-slow; ok {
+		t.Error("Expected slow subscriber channel to be closed")
+	}
+
+	receiveEvent(t, fast)
+	receiveEvent(t, fast)
+}
+
+func TestStoreCloseClosesSubscribers(t *testing.T) {
+	store := NewStore(time.Minute)
+	events := store.Subscribe(context.Background(), 0)
+
+	store.Close()
+
+	if _, ok := <-events; ok {
+		t.Error("Expected channel to be closed after store Close")
+	}
+
+	if _, ok := <-store.Subscribe(context.Background(), 0); ok {
+		t.Error("Expected subscription on closed store to be closed")
+	}
+}
+
+func TestStoreEventsSince(t *testing.T) {
+	store := NewStore(time.Minute)
+
+	store.Update(ContainerData{ID: "1"})
+	_, seq := store.Snapshot()
+	store.Update(ContainerData{ID: "2"})
+	store.Update(ContainerData{ID: "3"})
+
+	events, ok := store.EventsSince(seq)
+	if !ok {
+		t.Fatal("Expected events since snapshot to be available")
+	}
+	if len(events) != 2 || events[0].Container.ID != "2" || events[1].Container.ID != "3" {
+		t.Errorf("Got %+v, want events for containers 2 and 3", events)
+	}
+
+	if _, ok := store.EventsSince(seq + 100); ok {
+		t.Error("Expected sequence from the future to be rejected")
+	}
+
+	for i := 0; i < historySize+1; i++ {
+		store.UpdateStats("1", &Stats{})
+	}
+	if _, ok := store.EventsSince(seq); ok {
+		t.Error("Expected discarded history to require a snapshot")
+	}
+}
@@ -9,18 +9,137 @@ import (
 const (
 	StateStarting = "starting"
 	StateStopping = "stopping"
+	StateError    = "error"
 )
 
 // ContainerData represents container information for frontend consumption
 type ContainerData struct {
-	ID      string    `json:"id"`
-	Names   []string  `json:"names"`
-	Image   string    `json:"image"`
-	State   string    `json:"state"`
-	Status  string    `json:"status"`
-	Created int64     `json:"created"`
-	Stats   *Stats    `json:"stats,omitempty"`
-	Updated time.Time `json:"-"` // internal field for TTL
+	ID        string            `json:"id"`
+	Names     []string          `json:"names"`
+	Image     string            `json:"image"`
+	State     string            `json:"state"`
+	Status    string            `json:"status"`
+	Created   int64             `json:"created"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Ports     []Port            `json:"ports,omitempty"`
+	Compose   *ComposeInfo      `json:"compose,omitempty"` // Set for containers created by Docker Compose
+	Stats     *Stats            `json:"stats,omitempty"`
+	ExitCode  *int              `json:"exit_code,omitempty"`           // Code of the last exit, parsed from Status
+	Error     string            `json:"error,omitempty"`               // Last Docker error for this container
+	Deadline  *time.Time        `json:"transition_deadline,omitempty"` // When a starting/stopping transition times out
+	Removed   bool              `json:"removed,omitempty"`             // Tombstone of a container gone from Docker
+	RemovedAt *time.Time        `json:"removed_at,omitempty"`
+	// LastAction is the outcome of the most recent start/stop request
+	LastAction *ActionResult `json:"last_action,omitempty"`
+	// Diagnostics tracks restarts and exits seen in Docker events
+	Diagnostics *Diagnostics `json:"diagnostics,omitempty"`
+	// Health is set for containers with a healthcheck
+	Health *Health `json:"health,omitempty"`
+	// Probes are the synthetic checks duh runs against published ports
+	Probes  []Probe   `json:"probes,omitempty"`
+	Version uint64    `json:"version"` // store version of the last change to this record
+	Updated time.Time `json:"-"`       // internal field for TTL
+}
+
+// Action outcomes recorded in ActionResult
+const (
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+)
+
+// ActionResult records the outcome of an action requested for a container
+type ActionResult struct {
+	Action  string    `json:"action"`
+	Outcome string    `json:"outcome"`
+	Error   string    `json:"error,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// Port is a container port and, when published, the host address it is bound to
+type Port struct {
+	IP          string `json:"ip,omitempty"`
+	PrivatePort uint16 `json:"private_port"`
+	PublicPort  uint16 `json:"public_port,omitempty"`
+	Type        string `json:"type"`
+}
+
+// ComposeInfo identifies a container within a Docker Compose project
+type ComposeInfo struct {
+	Project string `json:"project"`
+	Service string `json:"service"`
+	Number  int    `json:"number,omitempty"` // Replica number of the service
+}
+
+// Problem kinds reported in Diagnostics
+const (
+	ProblemCrashLoop = "crash_loop"
+	ProblemOOMKilled = "oom_killed"
+)
+
+// Diagnostics describes how a container has been exiting
+type Diagnostics struct {
+	RestartCount int          `json:"restart_count"`
+	OOMKilled    bool         `json:"oom_killed"` // The most recent exit was an OOM kill
+	Exits        []ExitRecord `json:"recent_exits,omitempty"`
+	Problem      string       `json:"problem,omitempty"` // ProblemCrashLoop, ProblemOOMKilled or empty
+	Reason       string       `json:"reason,omitempty"`  // Human readable explanation of Problem
+}
+
+// ExitRecord is a single container exit
+type ExitRecord struct {
+	At        time.Time `json:"at"`
+	ExitCode  int       `json:"exit_code"`
+	OOMKilled bool      `json:"oom_killed,omitempty"`
+	Stopped   bool      `json:"stopped,omitempty"` // The exit followed a kill or stop request
+}
+
+// Healthcheck statuses
+const (
+	HealthStarting  = "starting"
+	HealthHealthy   = "healthy"
+	HealthUnhealthy = "unhealthy"
+)
+
+// Health is the healthcheck state of a container
+type Health struct {
+	Status        string        `json:"status"` // HealthStarting, HealthHealthy or HealthUnhealthy
+	FailingStreak int           `json:"failing_streak"`
+	Log           []HealthProbe `json:"log,omitempty"` // Most recent probes, oldest first
+}
+
+// HealthProbe is the result of a single healthcheck probe
+type HealthProbe struct {
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	ExitCode int       `json:"exit_code"`
+	Output   string    `json:"output"`
+}
+
+// Probe kinds and statuses
+const (
+	ProbeHTTP = "http"
+	ProbeTCP  = "tcp"
+
+	ProbeUp     = "up"
+	ProbeDown   = "down"
+	ProbePaused = "paused" // The container is not running
+)
+
+// Probe is a synthetic check of a container's published port
+type Probe struct {
+	Type    string        `json:"type"`    // ProbeHTTP or ProbeTCP
+	Target  string        `json:"target"`  // URL or host:port checked
+	Status  string        `json:"status"`  // ProbeUp, ProbeDown or ProbePaused
+	History []ProbeResult `json:"history"` // Most recent results, oldest first
+}
+
+// ProbeResult is the outcome of a single probe
+type ProbeResult struct {
+	At         time.Time `json:"at"`
+	Up         bool      `json:"up"`
+	LatencyMS  float64   `json:"latency_ms"`
+	StatusCode int       `json:"status_code,omitempty"` // HTTP probes only
+	Error      string    `json:"error,omitempty"`
 }
 
 // Stats represents container resource usage statistics for frontend display
@@ -34,42 +153,104 @@ type Stats struct {
 		Cores    uint32  `json:"cores"`     // Number of CPU cores
 		SystemMS uint64  `json:"system_ms"` // System CPU time in milliseconds
 	} `json:"cpu_stats"`
+	Network struct {
+		RxBytes uint64  `json:"rx_bytes"` // Total received over all interfaces
+		TxBytes uint64  `json:"tx_bytes"` // Total sent over all interfaces
+		RxRate  float64 `json:"rx_rate"`  // Bytes per second since the previous sample
+		TxRate  float64 `json:"tx_rate"`  // Bytes per second since the previous sample
+	} `json:"network_stats"`
+	BlockIO struct {
+		ReadBytes  uint64 `json:"read_bytes"`
+		WriteBytes uint64 `json:"write_bytes"`
+	} `json:"blkio_stats"`
+	Sampled time.Time `json:"-"` // When Docker read the stats, used to compute rates
 }
 
+// DefaultTombstoneTTL is how long removed containers are remembered unless configured otherwise
+const DefaultTombstoneTTL = time.Hour
+
 // Store represents an in-memory store for container data
 type Store struct {
-	mu         sync.RWMutex
-	containers map[string]ContainerData
-	ttl        time.Duration
-	done       chan struct{}
+	mu           sync.RWMutex
+	containers   map[string]ContainerData
+	ttl          time.Duration
+	done         chan struct{}
+	subscribers  map[*subscriber]struct{}
+	history      []Event
+	seq          uint64
+	tombstones   map[string]ContainerData
+	tombstoneTTL time.Duration
+	removedMin   uint64 // versions at or below this may have lost their removal records
+}
+
+// Option configures a Store
+type Option func(*Store)
+
+// WithTombstoneTTL sets how long records of removed containers are kept
+func WithTombstoneTTL(ttl time.Duration) Option {
+	return func(s *Store) {
+		s.tombstoneTTL = ttl
+	}
 }
 
 // NewStore creates a new store with the specified TTL for container data
-func NewStore(ttl time.Duration) *Store {
+func NewStore(ttl time.Duration, opts ...Option) *Store {
 	s := &Store{
-		containers: make(map[string]ContainerData),
-		ttl:        ttl,
-		done:       make(chan struct{}),
+		containers:   make(map[string]ContainerData),
+		ttl:          ttl,
+		done:         make(chan struct{}),
+		subscribers:  make(map[*subscriber]struct{}),
+		tombstones:   make(map[string]ContainerData),
+		tombstoneTTL: DefaultTombstoneTTL,
+		// Start versions from the clock so that they keep increasing across restarts
+		// and clients holding a version from a previous run are sent a full list.
+		// Microseconds keep the numbers within the exact integer range of JavaScript.
+		seq: uint64(time.Now().UnixMicro()),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	s.removedMin = s.seq
 	return s
 }
 
-// Close stops the cleanup goroutine
+// Close stops the cleanup goroutine and closes all subscriber channels
 func (s *Store) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	close(s.done)
+	for sub := range s.subscribers {
+		s.unsubscribe(sub)
+	}
 }
 
-// RemoveStaleData removes container data that hasn't been updated within TTL
+// RemoveStaleData removes container data that hasn't been updated within TTL,
+// leaving a tombstone behind, and forgets tombstones past their retention period
 func (s *Store) RemoveStaleData() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	now := time.Now()
-	for id, container := range s.containers {
-		if now.Sub(container.Updated) > s.ttl {
-			delete(s.containers, id)
+	for _, container := range s.containers {
+		if s.stale(container, now) {
+			s.commit(EventRemoved, container)
 		}
 	}
+	s.pruneTombstones(now)
+}
+
+// Remove replaces a container with a tombstone. It returns false if the container is unknown.
+func (s *Store) Remove(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	container, exists := s.containers[id]
+	if !exists {
+		return false
+	}
+	s.commit(EventRemoved, container)
+	return true
 }
 
 // Update adds or updates container data in the store
@@ -78,14 +259,103 @@ func (s *Store) Update(container ContainerData) {
 	defer s.mu.Unlock()
 
 	// Preserve stats for all states except exited
-	if existing, exists := s.containers[container.ID]; exists {
+	existing, exists := s.containers[container.ID]
+	if exists {
 		if container.State != "exited" {
 			container.Stats = existing.Stats
 		}
+		if container.LastAction == nil {
+			container.LastAction = existing.LastAction
+		}
+		if container.Diagnostics == nil {
+			container.Diagnostics = existing.Diagnostics
+		}
+		if container.Probes == nil {
+			container.Probes = existing.Probes
+		}
+		// Keep the probe details while the status they belong to is unchanged
+		if container.Health != nil && existing.Health != nil && container.Health.Status == existing.Health.Status {
+			container.Health = existing.Health
+		}
 	}
 
 	container.Updated = time.Now()
-	s.containers[container.ID] = container
+	container.Version = existing.Version
+
+	switch {
+	case !exists:
+		s.commit(EventAdded, container)
+	case !sameContainer(existing, container):
+		s.commit(EventUpdated, container)
+	default:
+		s.containers[container.ID] = container
+	}
+}
+
+// RecordAction sets the last action result of a container. It returns false if the container is unknown.
+func (s *Store) RecordAction(id string, result ActionResult) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	container, exists := s.containers[id]
+	if !exists {
+		return false
+	}
+
+	container.LastAction = &result
+	s.commit(EventUpdated, container)
+	return true
+}
+
+// SetDiagnostics replaces the diagnostics of a container. It returns false if the container is unknown.
+func (s *Store) SetDiagnostics(id string, diagnostics *Diagnostics) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	container, exists := s.containers[id]
+	if !exists {
+		return false
+	}
+
+	container.Diagnostics = diagnostics
+	if !sameContainer(s.containers[id], container) {
+		s.commit(EventUpdated, container)
+	}
+	return true
+}
+
+// SetHealth replaces the health of a container. It returns false if the container is unknown.
+func (s *Store) SetHealth(id string, health *Health) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	container, exists := s.containers[id]
+	if !exists {
+		return false
+	}
+
+	container.Health = health
+	if !sameContainer(s.containers[id], container) {
+		s.commit(EventUpdated, container)
+	}
+	return true
+}
+
+// SetProbes replaces the probe results of a container. It returns false if the container is unknown.
+func (s *Store) SetProbes(id string, probes []Probe) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	container, exists := s.containers[id]
+	if !exists {
+		return false
+	}
+
+	container.Probes = probes
+	if !sameContainer(s.containers[id], container) {
+		s.commit(EventUpdated, container)
+	}
+	return true
 }
 
 // UpdateStats updates stats for a specific container
@@ -96,7 +366,7 @@ func (s *Store) UpdateStats(id string, stats *Stats) bool {
 	if container, exists := s.containers[id]; exists {
 		container.Stats = stats
 		container.Updated = time.Now()
-		s.containers[id] = container
+		s.commit(EventStatsUpdated, container)
 		return true
 	}
 
@@ -108,11 +378,16 @@ func (s *Store) List() []ContainerData {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	return s.list()
+}
+
+// list returns all non-stale container data. Callers must hold s.mu.
+func (s *Store) list() []ContainerData {
 	now := time.Now()
 	result := make([]ContainerData, 0, len(s.containers))
 
 	for _, container := range s.containers {
-		if now.Sub(container.Updated) <= s.ttl {
+		if !s.stale(container, now) {
 			result = append(result, container)
 		}
 	}
@@ -130,9 +405,19 @@ func (s *Store) Get(id string) (ContainerData, bool) {
 		return ContainerData{}, false
 	}
 
-	if time.Since(container.Updated) > s.ttl {
+	if s.stale(container, time.Now()) {
 		return ContainerData{}, false
 	}
 
 	return container, true
 }
+
+// stale reports whether a container has not been updated within the TTL. Containers
+// in a starting or stopping transition are not refreshed by Sync, so they stay until
+// ExpireTransitions resolves them at their deadline.
+func (s *Store) stale(container ContainerData, now time.Time) bool {
+	if container.Deadline != nil && (container.State == StateStarting || container.State == StateStopping) {
+		return false
+	}
+	return now.Sub(container.Updated) > s.ttl
+}
@@ -0,0 +1,39 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// ExpireTransitions moves containers whose starting or stopping transition has passed
+// its deadline into StateError, keeping the last Docker error message when there is one.
+// It returns the containers that were expired.
+func (s *Store) ExpireTransitions(now time.Time) []ContainerData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []ContainerData
+	for _, container := range s.containers {
+		if container.State != StateStarting && container.State != StateStopping {
+			continue
+		}
+		if container.Deadline == nil || now.Before(*container.Deadline) {
+			continue
+		}
+
+		reason := fmt.Sprintf("timed out %s container", container.State)
+		if container.Error != "" {
+			reason += ": " + container.Error
+		}
+
+		container.State = StateError
+		container.Status = "Error"
+		container.Error = reason
+		container.Deadline = nil
+		container.Updated = now
+		s.commit(EventUpdated, container)
+		expired = append(expired, s.containers[container.ID])
+	}
+
+	return expired
+}
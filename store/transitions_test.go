@@ -0,0 +1,37 @@
+package store
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStoreExpireTransitions(t *testing.T) {
+	store := NewStore(time.Minute)
+
+	past := time.Now().Add(-time.Second)
+	future := time.Now().Add(time.Minute)
+	store.Update(ContainerData{ID: "overdue", State: StateStarting, Deadline: &past, Error: "connection refused"})
+	store.Update(ContainerData{ID: "pending", State: StateStopping, Deadline: &future})
+	store.Update(ContainerData{ID: "running", State: "running", Deadline: &past})
+
+	expired := store.ExpireTransitions(time.Now())
+	if len(expired) != 1 || expired[0].ID != "overdue" {
+		t.Fatalf("Expected only overdue to expire, got %+v", expired)
+	}
+
+	got, _ := store.Get("overdue")
+	if got.State != StateError {
+		t.Errorf("State = %q, want %q", got.State, StateError)
+	}
+	if !strings.Contains(got.Error, "timed out starting") || !strings.Contains(got.Error, "connection refused") {
+		t.Errorf("Error %q should describe the timeout and keep the Docker error", got.Error)
+	}
+	if got.Deadline != nil {
+		t.Error("Expected deadline to be cleared")
+	}
+
+	if got, _ := store.Get("pending"); got.State != StateStopping {
+		t.Errorf("Pending transition changed to %q", got.State)
+	}
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"sort"
+	"time"
+)
+
+// Version returns the current store version. It increases with every change,
+// including stats updates, and equals the sequence number of the latest event.
+func (s *Store) Version() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.seq
+}
+
+// Since returns the non-stale containers changed after the given version and the IDs of
+// containers removed after it, together with the current version. The boolean is false
+// when the removals since that version are no longer known, in which case the caller
+// must fall back to a full list.
+func (s *Store) Since(version uint64) ([]ContainerData, []string, uint64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if version < s.removedMin || version > s.seq {
+		return nil, nil, s.seq, false
+	}
+
+	now := time.Now()
+	changed := make([]ContainerData, 0)
+	for _, container := range s.containers {
+		if container.Version > version && !s.stale(container, now) {
+			changed = append(changed, container)
+		}
+	}
+
+	removed := make([]string, 0)
+	for id, tombstone := range s.tombstones {
+		if tombstone.Version > version {
+			removed = append(removed, id)
+		}
+	}
+
+	return changed, removed, s.seq, true
+}
+
+// Tombstones returns the records of removed containers still within their retention
+// period, most recently removed first
+func (s *Store) Tombstones() []ContainerData {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	result := make([]ContainerData, 0, len(s.tombstones))
+	for _, tombstone := range s.tombstones {
+		if now.Sub(*tombstone.RemovedAt) <= s.tombstoneTTL {
+			result = append(result, tombstone)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].RemovedAt.After(*result[j].RemovedAt)
+	})
+	return result
+}
+
+// pruneTombstones forgets tombstones that are past both the tombstone retention and
+// the TTL, which delta queries rely on to report removals. Callers must hold s.mu.
+func (s *Store) pruneTombstones(now time.Time) {
+	retention := s.tombstoneTTL
+	if s.ttl > retention {
+		retention = s.ttl
+	}
+
+	for id, tombstone := range s.tombstones {
+		if now.Sub(*tombstone.RemovedAt) > retention {
+			delete(s.tombstones, id)
+			if tombstone.Version > s.removedMin {
+				s.removedMin = tombstone.Version
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStoreVersion(t *testing.T) {
+	store := NewStore(time.Minute)
+	start := store.Version()
+
+	store.Update(ContainerData{ID: "1", State: "running"})
+	v1 := store.Version()
+	if v1 <= start {
+		t.Fatalf("Version did not increase after add: %d <= %d", v1, start)
+	}
+
+	// Unchanged updates keep the version
+	store.Update(ContainerData{ID: "1", State: "running"})
+	if got := store.Version(); got != v1 {
+		t.Errorf("Version changed on no-op update: %d != %d", got, v1)
+	}
+
+	got, _ := store.Get("1")
+	if got.Version != v1 {
+		t.Errorf("Container version = %d, want %d", got.Version, v1)
+	}
+
+	store.UpdateStats("1", &Stats{})
+	if got := store.Version(); got <= v1 {
+		t.Errorf("Version did not increase after stats update: %d <= %d", got, v1)
+	}
+}
+
+func TestStoreSince(t *testing.T) {
+	store := NewStore(100*time.Millisecond, WithTombstoneTTL(0))
+
+	store.Update(ContainerData{ID: "1"})
+	store.Update(ContainerData{ID: "2"})
+	base := store.Version()
+
+	store.Update(ContainerData{ID: "2", State: "exited"})
+	store.Update(ContainerData{ID: "3"})
+
+	changed, removed, version, ok := store.Since(base)
+	if !ok {
+		t.Fatal("Expected delta to be available")
+	}
+	if version != store.Version() {
+		t.Errorf("Since returned version %d, want %d", version, store.Version())
+	}
+	if len(changed) != 2 || len(removed) != 0 {
+		t.Errorf("Got %d changed and %d removed, want 2 and 0", len(changed), len(removed))
+	}
+
+	// Let everything but container 3 expire
+	time.Sleep(80 * time.Millisecond)
+	store.Update(ContainerData{ID: "3", State: "running"})
+	time.Sleep(40 * time.Millisecond)
+	store.RemoveStaleData()
+
+	_, removed, _, ok = store.Since(base)
+	if !ok || len(removed) != 2 {
+		t.Errorf("Expected 2 removed containers, got %v (ok=%v)", removed, ok)
+	}
+
+	// Once removal records expire, old versions can no longer be answered
+	time.Sleep(120 * time.Millisecond)
+	store.RemoveStaleData()
+	if _, _, _, ok := store.Since(base); ok {
+		t.Error("Expected delta from before pruned removals to be unavailable")
+	}
+
+	if _, _, _, ok := store.Since(store.Version() + 1); ok {
+		t.Error("Expected version from the future to be unavailable")
+	}
+}
+
+func TestStoreTombstones(t *testing.T) {
+	store := NewStore(time.Minute, WithTombstoneTTL(50*time.Millisecond))
+
+	exitCode := 137
+	stats := &Stats{}
+	stats.Memory.Usage = 1024
+	store.Update(ContainerData{ID: "1", State: "exited", ExitCode: &exitCode})
+	store.UpdateStats("1", stats)
+
+	if store.Remove("unknown") {
+		t.Error("Remove returned true for unknown container")
+	}
+	if !store.Remove("1") {
+		t.Fatal("Remove returned false for existing container")
+	}
+
+	if _, exists := store.Get("1"); exists {
+		t.Error("Removed container still returned by Get")
+	}
+	if list := store.List(); len(list) != 0 {
+		t.Errorf("Got %d containers after removal, want 0", len(list))
+	}
+
+	tombstones := store.Tombstones()
+	if len(tombstones) != 1 {
+		t.Fatalf("Got %d tombstones, want 1", len(tombstones))
+	}
+	got := tombstones[0]
+	if !got.Removed || got.RemovedAt == nil {
+		t.Error("Tombstone not marked as removed")
+	}
+	if got.State != "exited" || got.ExitCode == nil || *got.ExitCode != 137 {
+		t.Errorf("Tombstone lost last state or exit code: %+v", got)
+	}
+	if got.Stats == nil || got.Stats.Memory.Usage != 1024 {
+		t.Error("Tombstone lost last stats")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if len(store.Tombstones()) != 0 {
+		t.Error("Expected tombstone to expire after retention period")
+	}
+}